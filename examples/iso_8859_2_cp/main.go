@@ -21,9 +21,8 @@ func main() {
 		doc.SetUnicodeTranslator(unicodeTranslator)
 	}()
 
-	func() {
-		pdf := doc.Pdf()
-
+	// Register fonts on every pdf the document is rendered into
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
 		// Bold
 		jsonBytes, _ := os.ReadFile("./Roboto-Bold.json")
 		zBytes, _ := os.ReadFile("./Roboto-Bold.z")
@@ -33,7 +32,7 @@ func main() {
 		jsonBytes, _ = os.ReadFile("././Roboto-Regular.json")
 		zBytes, _ = os.ReadFile("./Roboto-Regular.z")
 		pdf.AddFontFromBytes("Roboto", "", jsonBytes, zBytes)
-	}()
+	})
 
 	// Set header
 	doc.SetHeader(&generator.HeaderFooter{
@@ -4,11 +4,9 @@
 //
 // Typical usage:
 //
-//	pdf, _ := doc.Build()
-//	var buf bytes.Buffer
-//	pdf.Output(&buf)
+//	pdfBytes, _ := doc.Bytes()
 //
-//	result, err := facturx.Attach(buf.Bytes(), doc, facturx.Options{
+//	result, err := facturx.Attach(pdfBytes, doc, facturx.Options{
 //	    Profile:     facturx.ProfileMinimum,
 //	    SellerTaxID: "FR12345678901",
 //	})
@@ -30,10 +28,24 @@ const xmlFilename = "factur-x.xml"
 // with an embedded sRGB ICC profile, and merges the required Factur-X XMP
 // declarations into the PDF's existing XMP packet.
 //
-// doc.Build() (which calls doc.Validate()) must have been called before Attach
-// so that all monetary values are computed.
+// pdfBytes is usually the output of doc.Bytes() or doc.Render(), which call
+// doc.Validate() so that all monetary values are computed.
+//
+// When opts.FailOnViolations is set, Attach runs Validate first and returns
+// the Violations with SeverityError, if any, instead of producing a PDF.
 func Attach(pdfBytes []byte, doc *generator.Document, opts Options) ([]byte, error) {
-	xmlBytes, err := BuildXML(doc, opts)
+	data, err := buildCIIData(doc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("facturx: build XML: %w", err)
+	}
+
+	if opts.FailOnViolations {
+		if violations := checkRules(data, opts.profile()).Errors(); len(violations) > 0 {
+			return nil, fmt.Errorf("facturx: %w", violations)
+		}
+	}
+
+	xmlBytes, err := renderXML(data)
 	if err != nil {
 		return nil, fmt.Errorf("facturx: build XML: %w", err)
 	}
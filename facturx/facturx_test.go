@@ -2,6 +2,7 @@ package facturx
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,9 @@ import (
 	"testing"
 
 	generator "github.com/angelodlfrtr/go-invoice-generator/generator"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 )
 
 func buildTestDoc(t *testing.T) *generator.Document {
@@ -112,6 +116,72 @@ func TestAttach(t *testing.T) {
 	}
 }
 
+// extGStates returns the graphics state parameter dictionaries of pdf
+func extGStates(t *testing.T, pdf []byte) []types.Dict {
+	t.Helper()
+
+	ctx, err := pdfcpuapi.ReadContext(bytes.NewReader(pdf), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("ReadContext: %v", err)
+	}
+
+	var states []types.Dict
+	for nr := range ctx.XRefTable.Table {
+		obj, err := ctx.Dereference(types.IndirectRef{ObjectNumber: types.Integer(nr)})
+		if err != nil {
+			continue
+		}
+		if d, ok := obj.(types.Dict); ok && d.Type() != nil && *d.Type() == "ExtGState" {
+			states = append(states, d)
+		}
+	}
+	return states
+}
+
+func TestAttachWatermark(t *testing.T) {
+	for _, over := range []bool{false, true} {
+		doc := buildTestDoc(t)
+		doc.SetWatermark(&generator.Watermark{Text: "DUPLICATE", Rotation: 45, Opacity: 0.2, OverContent: over})
+		doc.SetHeader(&generator.HeaderFooter{Text: "Acme Corp"})
+		doc.SetFooter(&generator.HeaderFooter{Text: "Thank you"})
+
+		result, err := Attach(buildPDF(t, doc), doc, Options{
+			Profile:           ProfileMinimum,
+			SellerTaxID:       "FR12345678901",
+			SellerCountryCode: "FR",
+			BuyerCountryCode:  "US",
+			CurrencyCode:      "EUR",
+			PaymentDueDate:    "20240201",
+			TaxCategoryCode:   "S",
+		})
+		if err != nil {
+			t.Fatalf("Attach: %v", err)
+		}
+
+		// PDF/A-2 and PDF/A-3 allow opacity with the Normal blend mode only
+		transparent := false
+		for _, gs := range extGStates(t, result) {
+			for _, key := range []string{"CA", "ca"} {
+				if alpha, ok := gs[key].(types.Float); ok && alpha < 1 {
+					transparent = true
+				}
+			}
+			if bm := gs.NameEntry("BM"); bm != nil && *bm != "Normal" {
+				t.Errorf("over %v: blend mode %s", over, *bm)
+			}
+		}
+		if transparent != over {
+			t.Errorf("over %v: transparency %v", over, transparent)
+		}
+
+		if over {
+			if err := os.WriteFile("../out/facturx_watermark.pdf", result, 0o644); err != nil {
+				t.Fatalf("write facturx_watermark.pdf: %v", err)
+			}
+		}
+	}
+}
+
 func TestAttachProfiles(t *testing.T) {
 	profiles := []Profile{
 		ProfileMinimum,
@@ -230,3 +300,272 @@ func TestBuildXML(t *testing.T) {
 		t.Fatal("BuildXML returned empty XML")
 	}
 }
+
+func TestBuildXMLShipTo(t *testing.T) {
+	doc := buildTestDoc(t)
+	doc.SetShipTo(&generator.Contact{
+		Name: "Acme Warehouse",
+		Address: &generator.Address{
+			Address:    "12 Dock Road",
+			PostalCode: "10001",
+			City:       "New York",
+			Country:    "US",
+		},
+	})
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("doc.Validate: %v", err)
+	}
+
+	xmlBytes, err := BuildXML(doc, Options{Profile: ProfileBasic, SellerTaxID: "FR12345678901"})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	for _, want := range []string{
+		"<ram:ShipToTradeParty>",
+		"<ram:Name>Acme Warehouse</ram:Name>",
+		"<ram:LineOne>12 Dock Road</ram:LineOne>",
+	} {
+		if !bytes.Contains(xmlBytes, []byte(want)) {
+			t.Errorf("XML does not contain %s", want)
+		}
+	}
+
+	// MINIMUM has no delivery information
+	xmlBytes, err = BuildXML(doc, Options{Profile: ProfileMinimum, SellerTaxID: "FR12345678901"})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	if !bytes.Contains(xmlBytes, []byte("<ram:ApplicableHeaderTradeDelivery/>")) {
+		t.Error("MINIMUM XML has a ship-to party")
+	}
+
+	doc.ShipTo.Address.Country = ""
+	violations, err := Validate(doc, Options{Profile: ProfileBasic, SellerTaxID: "FR12345678901"})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Rule != "BR-57" {
+		t.Errorf("expected a BR-57 violation, got %v", violations)
+	}
+}
+
+func TestBuildXMLContacts(t *testing.T) {
+	doc := buildTestDoc(t)
+	doc.Company.VATID = "FR12345678901"
+	doc.Company.RegistrationID = "123456789"
+	doc.Company.RegistrationScheme = "SIREN"
+	doc.Company.ContactPerson = "Jane Smith"
+	doc.Company.Email = "billing@acme.example"
+	doc.Customer.VATID = "DE123456789"
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("doc.Validate: %v", err)
+	}
+
+	xmlBytes, err := BuildXML(doc, Options{Profile: ProfileEN16931})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	for _, want := range []string{
+		`<ram:ID schemeID="0002">123456789</ram:ID>`,
+		"<ram:PersonName>Jane Smith</ram:PersonName>",
+		"<ram:URIID>billing@acme.example</ram:URIID>",
+		`<ram:ID schemeID="VA">FR12345678901</ram:ID>`,
+		`<ram:ID schemeID="VA">DE123456789</ram:ID>`,
+	} {
+		if !bytes.Contains(xmlBytes, []byte(want)) {
+			t.Errorf("XML does not contain %s", want)
+		}
+	}
+
+	// Options override the contact, contacts are EN16931 and above
+	xmlBytes, err = BuildXML(doc, Options{Profile: ProfileBasic, SellerTaxID: "FR98765432109"})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	if !bytes.Contains(xmlBytes, []byte(`<ram:ID schemeID="VA">FR98765432109</ram:ID>`)) {
+		t.Error("SellerTaxID does not override the company VAT ID")
+	}
+	if bytes.Contains(xmlBytes, []byte("<ram:DefinedTradeContact>")) {
+		t.Error("BASIC XML has a trade contact")
+	}
+
+	// The registration ID alone identifies the seller
+	doc.Company.VATID = ""
+	violations, err := Validate(doc, Options{Profile: ProfileMinimum})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+}
+
+func TestBuildXMLPayment(t *testing.T) {
+	doc := buildTestDoc(t)
+	doc.SetPayment(&generator.Payment{
+		Means:         generator.PaymentMeansSEPACreditTransfer,
+		IBAN:          "FR76 3000 6000 0112 3456 7890 189",
+		BIC:           "AGRIFRPP",
+		AccountHolder: "Acme Corp",
+		Reference:     "RF18539007547034",
+	})
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("doc.Validate: %v", err)
+	}
+
+	xmlBytes, err := BuildXML(doc, Options{Profile: ProfileEN16931, SellerTaxID: "FR12345678901"})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	for _, want := range []string{
+		"<ram:PaymentReference>RF18539007547034</ram:PaymentReference>",
+		"<ram:TypeCode>58</ram:TypeCode>",
+		"<ram:IBANID>FR7630006000011234567890189</ram:IBANID>",
+		"<ram:AccountName>Acme Corp</ram:AccountName>",
+		"<ram:BICID>AGRIFRPP</ram:BICID>",
+	} {
+		if !bytes.Contains(xmlBytes, []byte(want)) {
+			t.Errorf("XML does not contain %s", want)
+		}
+	}
+
+	// Options override the document
+	xmlBytes, err = BuildXML(doc, Options{Profile: ProfileBasic, SellerTaxID: "FR12345678901", PaymentMeansCode: "30"})
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	if !bytes.Contains(xmlBytes, []byte("<ram:TypeCode>30</ram:TypeCode>")) {
+		t.Error("PaymentMeansCode does not override the payment means")
+	}
+
+	doc.Payment.IBAN = ""
+	violations, err := Validate(doc, Options{Profile: ProfileBasic, SellerTaxID: "FR12345678901"})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Rule != "BR-61" {
+		t.Errorf("expected a BR-61 violation, got %v", violations)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	profiles := []Profile{
+		ProfileMinimum,
+		ProfileBasicWL,
+		ProfileBasic,
+		ProfileEN16931,
+		ProfileExtended,
+	}
+
+	for _, profile := range profiles {
+		t.Run(string(profile), func(t *testing.T) {
+			doc := buildTestDoc(t)
+			if err := doc.Validate(); err != nil {
+				t.Fatalf("doc.Validate: %v", err)
+			}
+
+			violations, err := Validate(doc, Options{
+				Profile:     profile,
+				SellerTaxID: "FR12345678901",
+			})
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if len(violations) > 0 {
+				t.Fatalf("unexpected violations: %v", violations)
+			}
+		})
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	doc := buildTestDoc(t)
+	doc.Company.Address.Country = ""
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("doc.Validate: %v", err)
+	}
+
+	violations, err := Validate(doc, Options{Profile: ProfileBasic})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	rules := map[string]Violation{}
+	for _, v := range violations {
+		rules[v.Rule] = v
+	}
+
+	for _, rule := range []string{"BR-09", "BR-CO-26", "BR-S-02"} {
+		v, ok := rules[rule]
+		if !ok {
+			t.Errorf("expected a %s violation, got %v", rule, violations)
+			continue
+		}
+		if v.Path == "" || v.Message == "" || v.Severity != SeverityError {
+			t.Errorf("incomplete violation %+v", v)
+		}
+	}
+}
+
+func TestValidatePaymentTerms(t *testing.T) {
+	doc := buildTestDoc(t)
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("doc.Validate: %v", err)
+	}
+	opts := Options{Profile: ProfileBasicWL, SellerTaxID: "FR12345678901"}
+
+	// The document payment term is exported as the payment terms (BT-20)
+	xmlBytes, err := BuildXML(doc, opts)
+	if err != nil {
+		t.Fatalf("BuildXML: %v", err)
+	}
+	if !bytes.Contains(xmlBytes, []byte("<ram:Description>01/02/2024</ram:Description>")) {
+		t.Error("XML does not contain the payment terms")
+	}
+
+	// BR-CO-25: an amount due needs a due date or payment terms
+	doc.PaymentTerm = ""
+	violations, err := Validate(doc, opts)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Rule != "BR-CO-25" {
+		t.Errorf("expected a BR-CO-25 violation, got %v", violations)
+	}
+
+	opts.PaymentDueDate = "20240201"
+	if violations, _ := Validate(doc, opts); len(violations) != 0 {
+		t.Errorf("due date does not satisfy BR-CO-25: %v", violations)
+	}
+
+	// Date formats are not EN 16931 rules
+	opts.PaymentDueDate = "2024-02-01"
+	violations, _ = Validate(doc, opts)
+	if len(violations) != 1 || violations[0].Rule != RuleDateFormat {
+		t.Errorf("expected a %s violation, got %v", RuleDateFormat, violations)
+	}
+
+	// MINIMUM has no payment terms
+	if violations, _ := Validate(doc, Options{Profile: ProfileMinimum, SellerTaxID: "FR12345678901"}); len(violations) != 0 {
+		t.Errorf("unexpected MINIMUM violations: %v", violations)
+	}
+}
+
+func TestAttachFailOnViolations(t *testing.T) {
+	doc := buildTestDoc(t)
+
+	_, err := Attach(buildPDF(t, doc), doc, Options{
+		Profile:          ProfileBasic,
+		FailOnViolations: true,
+	})
+
+	var violations Violations
+	if !errors.As(err, &violations) {
+		t.Fatalf("expected Violations error, got %v", err)
+	}
+
+	if len(violations) == 0 || len(violations.Errors()) != len(violations) {
+		t.Fatalf("expected only error violations, got %v", violations)
+	}
+}
@@ -11,7 +11,7 @@ type Options struct {
 	CurrencyCode string
 
 	// SellerTaxID is the seller's VAT registration number (e.g. "FR12345678901").
-	// Required for most profiles.
+	// Required for most profiles. Falls back to doc.Company.VATID when empty.
 	SellerTaxID string
 
 	// SellerCountryCode is the seller's ISO 3166-1 alpha-2 country code used in
@@ -22,25 +22,35 @@ type Options struct {
 	// the CII XML address. Falls back to doc.Customer.Address.Country when empty.
 	BuyerCountryCode string
 
+	// ShipToCountryCode is the ISO 3166-1 alpha-2 country code of the ship-to
+	// party. Falls back to doc.ShipTo.Address.Country when empty.
+	ShipToCountryCode string
+
 	// BuyerReference is the buyer's internal reference (e.g. a purchase order number).
 	BuyerReference string
 
 	// BuyerTaxID is the buyer's VAT registration number. Rendered in
 	// BuyerTradeParty/SpecifiedTaxRegistration for BASIC-WL and above.
+	// Falls back to doc.Customer.VATID when empty.
 	BuyerTaxID string
 
-	// PaymentDueDate is the payment due date in "YYYYMMDD" format.
+	// PaymentDueDate is the payment due date in "YYYYMMDD" format. From
+	// BASIC-WL on, BR-CO-25 requires it or the document payment term, which
+	// is exported as the payment terms, when an amount is due.
 	PaymentDueDate string
 
-	// PaymentIBAN is the seller's IBAN for bank transfer payment.
+	// PaymentIBAN is the seller's IBAN for bank transfer payment. Falls back
+	// to doc.Payment.IBAN when empty.
 	PaymentIBAN string
 
 	// PaymentBIC is the seller's BIC/SWIFT code for bank transfer payment.
+	// Falls back to doc.Payment.BIC when empty.
 	PaymentBIC string
 
 	// PaymentMeansCode is the UN/ECE 4461 payment means type code (e.g. "30" for
-	// credit transfer, "58" for SEPA credit transfer). Defaults to "58" when
-	// PaymentIBAN is set. Required for EN16931/EXTENDED when payment means are present.
+	// credit transfer, "58" for SEPA credit transfer). Falls back to
+	// doc.Payment.Means, then defaults to "58" when an IBAN is set. Required
+	// for EN16931/EXTENDED when payment means are present.
 	PaymentMeansCode string
 
 	// TaxCategoryCode is the default VAT category code applied when a tax rate has no
@@ -56,6 +66,10 @@ type Options struct {
 	// line items. Defaults to "C62" (piece/unit).
 	ItemDefaultUnitCode string
 
+	// FailOnViolations makes Attach run Validate and fail with the resulting
+	// Violations when an EN 16931 business rule is broken. Defaults to false.
+	FailOnViolations bool
+
 	// ShowIcon places the Factur-X profile icon in the bottom-right corner of
 	// the first page as a compliance mark. Defaults to false.
 	ShowIcon bool
@@ -96,16 +110,59 @@ func (o Options) itemDefaultUnitCode() string {
 	return "C62"
 }
 
-func (o Options) paymentMeansCode() string {
+func (o Options) paymentMeansCode(doc *generator.Document) string {
 	if o.PaymentMeansCode != "" {
 		return o.PaymentMeansCode
 	}
-	if o.PaymentIBAN != "" {
+	if doc.Payment != nil && doc.Payment.Means != "" {
+		return doc.Payment.Means
+	}
+	if o.paymentIBAN(doc) != "" {
 		return "58"
 	}
 	return ""
 }
 
+func (o Options) paymentIBAN(doc *generator.Document) string {
+	if o.PaymentIBAN != "" {
+		return o.PaymentIBAN
+	}
+	if doc.Payment != nil {
+		return doc.Payment.CompactIBAN()
+	}
+	return ""
+}
+
+func (o Options) paymentBIC(doc *generator.Document) string {
+	if o.PaymentBIC != "" {
+		return o.PaymentBIC
+	}
+	if doc.Payment != nil {
+		return doc.Payment.BIC
+	}
+	return ""
+}
+
+func (o Options) sellerTaxID(doc *generator.Document) string {
+	if o.SellerTaxID != "" {
+		return o.SellerTaxID
+	}
+	if doc.Company != nil {
+		return doc.Company.VATID
+	}
+	return ""
+}
+
+func (o Options) buyerTaxID(doc *generator.Document) string {
+	if o.BuyerTaxID != "" {
+		return o.BuyerTaxID
+	}
+	if doc.Customer != nil {
+		return doc.Customer.VATID
+	}
+	return ""
+}
+
 func (o Options) sellerCountryCode(doc *generator.Document) string {
 	if o.SellerCountryCode != "" {
 		return o.SellerCountryCode
@@ -125,3 +182,13 @@ func (o Options) buyerCountryCode(doc *generator.Document) string {
 	}
 	return ""
 }
+
+func (o Options) shipToCountryCode(doc *generator.Document) string {
+	if o.ShipToCountryCode != "" {
+		return o.ShipToCountryCode
+	}
+	if doc.ShipTo != nil && doc.ShipTo.Address != nil {
+		return doc.ShipTo.Address.Country
+	}
+	return ""
+}
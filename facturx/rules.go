@@ -0,0 +1,480 @@
+package facturx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	generator "github.com/angelodlfrtr/go-invoice-generator/generator"
+	"github.com/shopspring/decimal"
+)
+
+// Severity is the level of a business rule violation.
+type Severity string
+
+const (
+	// SeverityError marks a violation that makes the invoice non-conformant.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks a violation that receivers may tolerate.
+	SeverityWarning Severity = "warning"
+)
+
+// Violation is a broken EN 16931 business rule.
+type Violation struct {
+	// Rule is the EN 16931 rule identifier, e.g. "BR-CO-15", or RuleDateFormat
+	// for the checks of this package that are not EN 16931 business rules.
+	Rule string `json:"rule"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+
+	// Path locates the offending element in the CII XML.
+	Path string `json:"path"`
+
+	Severity Severity `json:"severity"`
+}
+
+// RuleDateFormat is the Rule of violations reporting a date that is not in
+// the YYYYMMDD format of CII dates (format 102).
+const RuleDateFormat = "FX-DATE"
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s (%s)", v.Rule, v.Message, v.Path)
+}
+
+// Violations is the result of Validate. It implements error so that Attach
+// can return it when Options.FailOnViolations is set; use errors.As to
+// retrieve it.
+type Violations []Violation
+
+// Error joins the violations messages.
+func (vs Violations) Error() string {
+	msgs := make([]string, len(vs))
+	for i, v := range vs {
+		msgs[i] = v.String()
+	}
+	return "EN 16931 business rules violated: " + strings.Join(msgs, "; ")
+}
+
+// Errors returns the violations with SeverityError.
+func (vs Violations) Errors() Violations {
+	var errs Violations
+	for _, v := range vs {
+		if v.Severity == SeverityError {
+			errs = append(errs, v)
+		}
+	}
+	return errs
+}
+
+// CII element paths used in violations.
+const (
+	pathDocument    = "/rsm:CrossIndustryInvoice/rsm:ExchangedDocument"
+	pathTransaction = "/rsm:CrossIndustryInvoice/rsm:SupplyChainTradeTransaction"
+	pathAgreement   = pathTransaction + "/ram:ApplicableHeaderTradeAgreement"
+	pathSeller      = pathAgreement + "/ram:SellerTradeParty"
+	pathBuyer       = pathAgreement + "/ram:BuyerTradeParty"
+	pathShipTo      = pathTransaction + "/ram:ApplicableHeaderTradeDelivery/ram:ShipToTradeParty"
+	pathSettlement  = pathTransaction + "/ram:ApplicableHeaderTradeSettlement"
+	pathSummation   = pathSettlement + "/ram:SpecifiedTradeSettlementHeaderMonetarySummation"
+)
+
+var (
+	countryCodeRe  = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyCodeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+	vatIDPrefixRe  = regexp.MustCompile(`^[A-Z]{2}`)
+	dateRe         = regexp.MustCompile(`^\d{8}$`)
+	icdRe          = regexp.MustCompile(`^\d{4}$`)
+)
+
+// Validate checks the Factur-X data generated for doc and opts against the
+// EN 16931 business rules (BR-*, BR-CO-*, BR-S-*, BR-E-* and BR-AE-*) that
+// apply to the selected profile. It works offline and does not replace a
+// full schematron validation.
+//
+// doc.Validate() (called by doc.Build()) must have run before calling this.
+func Validate(doc *generator.Document, opts Options) (Violations, error) {
+	data, err := buildCIIData(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return checkRules(data, opts.profile()), nil
+}
+
+// ruleChecker accumulates the violations of one ciiData.
+type ruleChecker struct {
+	data       *ciiData
+	profile    Profile
+	violations Violations
+}
+
+func (c *ruleChecker) fail(rule, path, format string, args ...any) {
+	c.violations = append(c.violations, Violation{
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     path,
+		Severity: SeverityError,
+	})
+}
+
+func (c *ruleChecker) warn(rule, path, format string, args ...any) {
+	c.violations = append(c.violations, Violation{
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     path,
+		Severity: SeverityWarning,
+	})
+}
+
+func (c *ruleChecker) required(rule, path, value, name string) bool {
+	if strings.TrimSpace(value) == "" {
+		c.fail(rule, path, "%s is required", name)
+		return false
+	}
+	return true
+}
+
+// amount parses a monetary value; unparsable values are reported once under rule.
+func (c *ruleChecker) amount(rule, path, value string) (decimal.Decimal, bool) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		c.fail(rule, path, "%q is not a valid amount", value)
+		return decimal.Zero, false
+	}
+	return d, true
+}
+
+func checkRules(data *ciiData, profile Profile) Violations {
+	c := &ruleChecker{data: data, profile: profile}
+
+	c.checkDocument()
+	c.checkParties()
+	c.checkTotals()
+
+	if profile != ProfileMinimum {
+		c.checkTaxBreakdown()
+		c.checkCategories()
+	}
+
+	if data.HasLineItems {
+		c.checkLines()
+	}
+
+	return c.violations
+}
+
+func (c *ruleChecker) checkDocument() {
+	d := c.data
+	c.required("BR-01", "/rsm:CrossIndustryInvoice/rsm:ExchangedDocumentContext/ram:GuidelineSpecifiedDocumentContextParameter/ram:ID", d.GuidelineID, "Specification identifier")
+	c.required("BR-02", pathDocument+"/ram:ID", d.ID, "Invoice number")
+	if c.required("BR-03", pathDocument+"/ram:IssueDateTime", d.IssueDate, "Invoice issue date") && !dateRe.MatchString(d.IssueDate) {
+		c.fail(RuleDateFormat, pathDocument+"/ram:IssueDateTime", "Invoice issue date %q is not in YYYYMMDD format", d.IssueDate)
+	}
+	c.required("BR-04", pathDocument+"/ram:TypeCode", d.TypeCode, "Invoice type code")
+	if c.required("BR-05", pathSettlement+"/ram:InvoiceCurrencyCode", d.CurrencyCode, "Invoice currency code") && !currencyCodeRe.MatchString(d.CurrencyCode) {
+		c.fail("BR-05", pathSettlement+"/ram:InvoiceCurrencyCode", "Invoice currency code %q is not an ISO 4217 code", d.CurrencyCode)
+	}
+	if d.PaymentDueDate != "" && !dateRe.MatchString(d.PaymentDueDate) {
+		c.fail(RuleDateFormat, pathSettlement+"/ram:SpecifiedTradePaymentTerms/ram:DueDateDateTime", "Payment due date %q is not in YYYYMMDD format", d.PaymentDueDate)
+	}
+	if (d.PaymentMeansCode == "30" || d.PaymentMeansCode == "58") && d.PaymentIBAN == "" {
+		c.fail("BR-61", pathSettlement+"/ram:SpecifiedTradeSettlementPaymentMeans/ram:PayeePartyCreditorFinancialAccount/ram:IBANID", "Payment account identifier is required for credit transfers")
+	}
+}
+
+func (c *ruleChecker) checkParties() {
+	d := c.data
+
+	c.required("BR-06", pathSeller+"/ram:Name", d.SellerName, "Seller name")
+	c.required("BR-07", pathBuyer+"/ram:Name", d.BuyerName, "Buyer name")
+
+	if d.SellerAddress == nil {
+		c.fail("BR-08", pathSeller+"/ram:PostalTradeAddress", "Seller postal address is required")
+	} else if c.required("BR-09", pathSeller+"/ram:PostalTradeAddress/ram:CountryID", d.SellerAddress.Country, "Seller country code") &&
+		!countryCodeRe.MatchString(d.SellerAddress.Country) {
+		c.fail("BR-09", pathSeller+"/ram:PostalTradeAddress/ram:CountryID", "Seller country code %q is not an ISO 3166-1 alpha-2 code", d.SellerAddress.Country)
+	}
+
+	// The buyer address is not part of the MINIMUM profile.
+	if c.profile != ProfileMinimum {
+		if d.BuyerAddress == nil {
+			c.fail("BR-10", pathBuyer+"/ram:PostalTradeAddress", "Buyer postal address is required")
+		} else if c.required("BR-11", pathBuyer+"/ram:PostalTradeAddress/ram:CountryID", d.BuyerAddress.Country, "Buyer country code") &&
+			!countryCodeRe.MatchString(d.BuyerAddress.Country) {
+			c.fail("BR-11", pathBuyer+"/ram:PostalTradeAddress/ram:CountryID", "Buyer country code %q is not an ISO 3166-1 alpha-2 code", d.BuyerAddress.Country)
+		}
+	}
+
+	// The deliver to address is optional, its country code is not.
+	if d.ShipToAddress != nil && c.required("BR-57", pathShipTo+"/ram:PostalTradeAddress/ram:CountryID", d.ShipToAddress.Country, "Deliver to country code") &&
+		!countryCodeRe.MatchString(d.ShipToAddress.Country) {
+		c.fail("BR-57", pathShipTo+"/ram:PostalTradeAddress/ram:CountryID", "Deliver to country code %q is not an ISO 3166-1 alpha-2 code", d.ShipToAddress.Country)
+	}
+
+	if d.SellerTaxID == "" && d.SellerLegalID == "" {
+		c.fail("BR-CO-26", pathSeller, "Seller VAT identifier or legal registration identifier is required")
+	}
+
+	if d.SellerTaxID != "" && !vatIDPrefixRe.MatchString(d.SellerTaxID) {
+		c.fail("BR-CO-9", pathSeller+"/ram:SpecifiedTaxRegistration/ram:ID", "Seller VAT identifier %q must start with an ISO 3166-1 alpha-2 country prefix", d.SellerTaxID)
+	}
+	if d.BuyerTaxID != "" && !vatIDPrefixRe.MatchString(d.BuyerTaxID) {
+		c.fail("BR-CO-9", pathBuyer+"/ram:SpecifiedTaxRegistration/ram:ID", "Buyer VAT identifier %q must start with an ISO 3166-1 alpha-2 country prefix", d.BuyerTaxID)
+	}
+}
+
+func (c *ruleChecker) checkTotals() {
+	d := c.data
+
+	taxBasis, okBasis := c.amount("BR-13", pathSummation+"/ram:TaxBasisTotalAmount", d.TaxBasisTotalAmount)
+	taxTotal, okTax := c.amount("BR-CO-14", pathSummation+"/ram:TaxTotalAmount", d.TaxTotalAmount)
+	grandTotal, okGrand := c.amount("BR-14", pathSummation+"/ram:GrandTotalAmount", d.GrandTotalAmount)
+
+	// DuePayableAmount is GrandTotalAmount. The MINIMUM profile has no
+	// payment terms.
+	if c.profile != ProfileMinimum && okGrand && grandTotal.IsPositive() && d.PaymentDueDate == "" && strings.TrimSpace(d.PaymentTerms) == "" {
+		c.fail("BR-CO-25", pathSettlement+"/ram:SpecifiedTradePaymentTerms",
+			"Payment due date or payment terms are required when the amount due for payment %s is positive", grandTotal)
+	}
+
+	// DuePayableAmount (BR-15) is always written from GrandTotalAmount, so
+	// BR-CO-16 holds as long as BR-14 does.
+	if okBasis && okTax && okGrand && !grandTotal.Equal(taxBasis.Add(taxTotal)) {
+		c.fail("BR-CO-15", pathSummation+"/ram:GrandTotalAmount",
+			"Invoice total amount with VAT %s must equal total without VAT %s plus total VAT %s", grandTotal, taxBasis, taxTotal)
+	}
+
+	if !d.HasLineTotalAmount {
+		return
+	}
+
+	lineTotal, okLine := c.amount("BR-12", pathSummation+"/ram:LineTotalAmount", d.LineTotalAmount)
+
+	allowanceTotal := decimal.Zero
+	if d.HasAllowance {
+		var ok bool
+		if allowanceTotal, ok = c.amount("BR-CO-11", pathSummation+"/ram:AllowanceTotalAmount", d.AllowanceTotalAmount); ok {
+			sum := decimal.Zero
+			for _, a := range d.DocAllowances {
+				amount, _ := decimal.NewFromString(a.ActualAmount)
+				sum = sum.Add(amount)
+			}
+			if !sum.Equal(allowanceTotal) {
+				c.fail("BR-CO-11", pathSummation+"/ram:AllowanceTotalAmount",
+					"Sum of allowances on document level %s must equal the sum of document level allowances %s", allowanceTotal, sum)
+			}
+		}
+	}
+
+	if okLine && okBasis && !taxBasis.Equal(lineTotal.Sub(allowanceTotal)) {
+		c.fail("BR-CO-13", pathSummation+"/ram:TaxBasisTotalAmount",
+			"Invoice total amount without VAT %s must equal sum of line net amounts %s minus allowances %s", taxBasis, lineTotal, allowanceTotal)
+	}
+
+	if !d.HasLineItems || !okLine {
+		return
+	}
+
+	sum := decimal.Zero
+	for _, li := range d.LineItems {
+		amount, _ := decimal.NewFromString(li.LineTotal)
+		sum = sum.Add(amount)
+	}
+	if !sum.Equal(lineTotal) {
+		c.fail("BR-CO-10", pathSummation+"/ram:LineTotalAmount",
+			"Sum of invoice line net amounts %s must equal the line total amount %s", sum, lineTotal)
+	}
+}
+
+func (c *ruleChecker) checkTaxBreakdown() {
+	d := c.data
+	path := pathSettlement + "/ram:ApplicableTradeTax"
+
+	if len(d.TaxBreakdown) == 0 {
+		c.fail("BR-CO-18", path, "Invoice must contain at least one VAT breakdown group")
+		return
+	}
+
+	taxSum := decimal.Zero
+	for i, tl := range d.TaxBreakdown {
+		p := fmt.Sprintf("%s[%d]", path, i+1)
+
+		basis, okBasis := c.amount("BR-45", p+"/ram:BasisAmount", tl.BasisAmount)
+		tax, okTax := c.amount("BR-46", p+"/ram:CalculatedAmount", tl.TaxAmount)
+		c.required("BR-47", p+"/ram:CategoryCode", tl.CategoryCode, "VAT category code")
+		taxSum = taxSum.Add(tax)
+
+		if tl.Percent == "" {
+			if tl.CategoryCode != "O" {
+				c.fail("BR-48", p+"/ram:RateApplicablePercent", "VAT category rate is required (fixed-amount taxes cannot be expressed as VAT)")
+			}
+			continue
+		}
+
+		rate, okRate := c.amount("BR-48", p+"/ram:RateApplicablePercent", tl.Percent)
+		if okBasis && okTax && okRate {
+			expected := basis.Mul(rate).Div(decimal.NewFromInt(100)).Round(2)
+			if tax.Sub(expected).Abs().GreaterThan(decimal.RequireFromString("0.01")) {
+				c.fail("BR-CO-17", p+"/ram:CalculatedAmount",
+					"VAT category tax amount %s must equal taxable amount %s × rate %s %% = %s", tax, basis, rate, expected)
+			}
+		}
+	}
+
+	if taxTotal, err := decimal.NewFromString(d.TaxTotalAmount); err == nil && !taxTotal.Equal(taxSum) {
+		c.fail("BR-CO-14", pathSummation+"/ram:TaxTotalAmount",
+			"Invoice total VAT amount %s must equal the sum of VAT category tax amounts %s", taxTotal, taxSum)
+	}
+}
+
+// checkCategories applies the BR-S, BR-E and BR-AE rules of the VAT
+// category used by the invoice.
+func (c *ruleChecker) checkCategories() {
+	d := c.data
+
+	categories := map[string]bool{}
+	for _, tl := range d.TaxBreakdown {
+		categories[tl.CategoryCode] = true
+	}
+	for _, li := range d.LineItems {
+		categories[li.TaxCategoryCode] = true
+	}
+
+	sellerVAT := pathSeller + "/ram:SpecifiedTaxRegistration"
+	buyerVAT := pathBuyer + "/ram:SpecifiedTaxRegistration"
+
+	if categories["S"] && d.SellerTaxID == "" {
+		c.fail("BR-S-02", sellerVAT, "Seller VAT identifier is required when standard rated VAT is applied")
+	}
+
+	if categories["E"] {
+		if d.SellerTaxID == "" {
+			c.fail("BR-E-02", sellerVAT, "Seller VAT identifier is required for VAT exempt invoices")
+		}
+		c.warn("BR-E-10", pathSettlement+"/ram:ApplicableTradeTax/ram:ExemptionReason", "VAT exemption reason is not exported by this generator")
+	}
+
+	if categories["AE"] {
+		if d.SellerTaxID == "" {
+			c.fail("BR-AE-02", sellerVAT, "Seller VAT identifier is required for reverse charge invoices")
+		}
+		if d.BuyerTaxID == "" {
+			c.fail("BR-AE-02", buyerVAT, "Buyer VAT identifier is required for reverse charge invoices")
+		}
+		c.warn("BR-AE-10", pathSettlement+"/ram:ApplicableTradeTax/ram:ExemptionReason", "VAT exemption reason \"Reverse charge\" is not exported by this generator")
+	}
+
+	for i, tl := range d.TaxBreakdown {
+		p := fmt.Sprintf("%s/ram:ApplicableTradeTax[%d]", pathSettlement, i+1)
+		rate, _ := decimal.NewFromString(tl.Percent)
+		tax, _ := decimal.NewFromString(tl.TaxAmount)
+
+		switch tl.CategoryCode {
+		case "S":
+			if !rate.IsPositive() {
+				c.fail("BR-S-05", p+"/ram:RateApplicablePercent", "Standard rated VAT must have a rate greater than zero")
+			}
+		case "E", "AE":
+			if !rate.IsZero() {
+				c.fail("BR-"+tl.CategoryCode+"-05", p+"/ram:RateApplicablePercent", "VAT category %q must have a rate of 0", tl.CategoryCode)
+			}
+			if !tax.IsZero() {
+				c.fail("BR-"+tl.CategoryCode+"-09", p+"/ram:CalculatedAmount", "VAT category %q tax amount must be 0", tl.CategoryCode)
+			}
+		}
+	}
+
+	if d.HasLineItems {
+		c.checkCategoryBases()
+	}
+}
+
+// checkCategoryBases checks that each VAT breakdown basis equals the sum of
+// the line net amounts at that rate minus the document allowances at that
+// rate (BR-S-08, BR-E-08, BR-AE-08).
+func (c *ruleChecker) checkCategoryBases() {
+	d := c.data
+
+	type bases struct{ lines, allowances decimal.Decimal }
+	byRate := map[string]*bases{}
+	key := func(category, percent string) string {
+		rate, _ := decimal.NewFromString(percent)
+		return category + "/" + rate.String()
+	}
+	get := func(k string) *bases {
+		if byRate[k] == nil {
+			byRate[k] = &bases{}
+		}
+		return byRate[k]
+	}
+
+	for _, li := range d.LineItems {
+		amount, _ := decimal.NewFromString(li.LineTotal)
+		b := get(key(li.TaxCategoryCode, li.TaxPercent))
+		b.lines = b.lines.Add(amount)
+	}
+	for _, a := range d.DocAllowances {
+		amount, _ := decimal.NewFromString(a.ActualAmount)
+		b := get(key(a.CategoryCode, a.Percent))
+		b.allowances = b.allowances.Add(amount)
+	}
+
+	for i, tl := range d.TaxBreakdown {
+		switch tl.CategoryCode {
+		case "S", "E", "AE":
+		default:
+			continue
+		}
+
+		b, ok := byRate[key(tl.CategoryCode, tl.Percent)]
+		if !ok {
+			continue
+		}
+		basis, _ := decimal.NewFromString(tl.BasisAmount)
+		expected := b.lines.Sub(b.allowances)
+		if !basis.Equal(expected) {
+			c.fail("BR-"+tl.CategoryCode+"-08", fmt.Sprintf("%s/ram:ApplicableTradeTax[%d]/ram:BasisAmount", pathSettlement, i+1),
+				"VAT category taxable amount %s must equal sum of line net amounts minus allowances at this rate %s", basis, expected)
+		}
+	}
+}
+
+func (c *ruleChecker) checkLines() {
+	d := c.data
+	path := pathTransaction + "/ram:IncludedSupplyChainTradeLineItem"
+
+	if len(d.LineItems) == 0 {
+		c.fail("BR-16", path, "Invoice must have at least one invoice line")
+		return
+	}
+
+	for i, li := range d.LineItems {
+		p := fmt.Sprintf("%s[%d]", path, i+1)
+
+		c.required("BR-21", p+"/ram:AssociatedDocumentLineDocument/ram:LineID", li.LineID, "Invoice line identifier")
+		c.required("BR-22", p+"/ram:SpecifiedLineTradeDelivery/ram:BilledQuantity", li.Quantity, "Invoiced quantity")
+		c.required("BR-23", p+"/ram:SpecifiedLineTradeDelivery/ram:BilledQuantity/@unitCode", d.UnitCode, "Invoiced quantity unit of measure code")
+		c.required("BR-24", p+"/ram:SpecifiedLineTradeSettlement/ram:SpecifiedTradeSettlementLineMonetarySummation/ram:LineTotalAmount", li.LineTotal, "Invoice line net amount")
+		c.required("BR-25", p+"/ram:SpecifiedTradeProduct/ram:Name", li.Name, "Item name")
+
+		netPricePath := p + "/ram:SpecifiedLineTradeAgreement/ram:NetPriceProductTradePrice/ram:ChargeAmount"
+		if c.required("BR-26", netPricePath, li.UnitPrice, "Item net price") {
+			if price, ok := c.amount("BR-26", netPricePath, li.UnitPrice); ok && price.IsNegative() {
+				c.fail("BR-27", netPricePath, "Item net price %s must not be negative", price)
+			}
+		}
+
+		if li.GrossUnitPrice != "" {
+			grossPath := p + "/ram:SpecifiedLineTradeAgreement/ram:GrossPriceProductTradePrice/ram:ChargeAmount"
+			if price, ok := c.amount("BR-28", grossPath, li.GrossUnitPrice); ok && price.IsNegative() {
+				c.fail("BR-28", grossPath, "Item gross price %s must not be negative", price)
+			}
+		}
+
+		if li.TaxCategoryCode == "S" && li.TaxPercent == "" {
+			c.fail("BR-S-05", p+"/ram:SpecifiedLineTradeSettlement/ram:ApplicableTradeTax/ram:RateApplicablePercent",
+				"Standard rated invoice line must have a VAT rate")
+		}
+	}
+}
@@ -83,6 +83,18 @@ const ciiXMLTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 		<ram:ApplicableHeaderTradeAgreement>
 			<ram:SellerTradeParty>
 				<ram:Name>{{xe .SellerName}}</ram:Name>
+				{{- if .SellerLegalID}}
+				<ram:SpecifiedLegalOrganization>
+					<ram:ID{{if .SellerLegalScheme}} schemeID="{{.SellerLegalScheme}}"{{end}}>{{xe .SellerLegalID}}</ram:ID>
+				</ram:SpecifiedLegalOrganization>
+				{{- end}}
+				{{- with .SellerContact}}
+				<ram:DefinedTradeContact>
+					{{- if .PersonName}}<ram:PersonName>{{xe .PersonName}}</ram:PersonName>{{- end}}
+					{{- if .Phone}}<ram:TelephoneUniversalCommunication><ram:CompleteNumber>{{xe .Phone}}</ram:CompleteNumber></ram:TelephoneUniversalCommunication>{{- end}}
+					{{- if .Email}}<ram:EmailURIUniversalCommunication><ram:URIID>{{xe .Email}}</ram:URIID></ram:EmailURIUniversalCommunication>{{- end}}
+				</ram:DefinedTradeContact>
+				{{- end}}
 				{{- if .SellerAddress}}
 				<ram:PostalTradeAddress>
 					{{- if .SellerAddress.PostalCode}}<ram:PostcodeCode>{{xe .SellerAddress.PostalCode}}</ram:PostcodeCode>{{- end}}
@@ -100,6 +112,18 @@ const ciiXMLTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 			</ram:SellerTradeParty>
 			<ram:BuyerTradeParty>
 				<ram:Name>{{xe .BuyerName}}</ram:Name>
+				{{- if .BuyerLegalID}}
+				<ram:SpecifiedLegalOrganization>
+					<ram:ID{{if .BuyerLegalScheme}} schemeID="{{.BuyerLegalScheme}}"{{end}}>{{xe .BuyerLegalID}}</ram:ID>
+				</ram:SpecifiedLegalOrganization>
+				{{- end}}
+				{{- with .BuyerContact}}
+				<ram:DefinedTradeContact>
+					{{- if .PersonName}}<ram:PersonName>{{xe .PersonName}}</ram:PersonName>{{- end}}
+					{{- if .Phone}}<ram:TelephoneUniversalCommunication><ram:CompleteNumber>{{xe .Phone}}</ram:CompleteNumber></ram:TelephoneUniversalCommunication>{{- end}}
+					{{- if .Email}}<ram:EmailURIUniversalCommunication><ram:URIID>{{xe .Email}}</ram:URIID></ram:EmailURIUniversalCommunication>{{- end}}
+				</ram:DefinedTradeContact>
+				{{- end}}
 				{{- if .BuyerAddress}}
 				<ram:PostalTradeAddress>
 					{{- if .BuyerAddress.PostalCode}}<ram:PostcodeCode>{{xe .BuyerAddress.PostalCode}}</ram:PostcodeCode>{{- end}}
@@ -120,15 +144,39 @@ const ciiXMLTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 			{{- end}}
 		</ram:ApplicableHeaderTradeAgreement>
 
+		{{- if .ShipToName}}
+		<ram:ApplicableHeaderTradeDelivery>
+			<ram:ShipToTradeParty>
+				<ram:Name>{{xe .ShipToName}}</ram:Name>
+				{{- if .ShipToAddress}}
+				<ram:PostalTradeAddress>
+					{{- if .ShipToAddress.PostalCode}}<ram:PostcodeCode>{{xe .ShipToAddress.PostalCode}}</ram:PostcodeCode>{{- end}}
+					{{- if .ShipToAddress.Address}}<ram:LineOne>{{xe .ShipToAddress.Address}}</ram:LineOne>{{- end}}
+					{{- if .ShipToAddress.Address2}}<ram:LineTwo>{{xe .ShipToAddress.Address2}}</ram:LineTwo>{{- end}}
+					{{- if .ShipToAddress.City}}<ram:CityName>{{xe .ShipToAddress.City}}</ram:CityName>{{- end}}
+					{{- if .ShipToAddress.Country}}<ram:CountryID>{{xe .ShipToAddress.Country}}</ram:CountryID>{{- end}}
+				</ram:PostalTradeAddress>
+				{{- end}}
+			</ram:ShipToTradeParty>
+		</ram:ApplicableHeaderTradeDelivery>
+		{{- else}}
 		<ram:ApplicableHeaderTradeDelivery/>
+		{{- end}}
 
 		<ram:ApplicableHeaderTradeSettlement>
+			{{- if .PaymentReference}}
+			<ram:PaymentReference>{{xe .PaymentReference}}</ram:PaymentReference>
+			{{- end}}
+			<ram:InvoiceCurrencyCode>{{.CurrencyCode}}</ram:InvoiceCurrencyCode>
 			{{- if .PaymentMeansCode}}
 			<ram:SpecifiedTradeSettlementPaymentMeans>
 				<ram:TypeCode>{{.PaymentMeansCode}}</ram:TypeCode>
 				{{- if .PaymentIBAN}}
 				<ram:PayeePartyCreditorFinancialAccount>
 					<ram:IBANID>{{xe .PaymentIBAN}}</ram:IBANID>
+					{{- if .PaymentAccountName}}
+					<ram:AccountName>{{xe .PaymentAccountName}}</ram:AccountName>
+					{{- end}}
 				</ram:PayeePartyCreditorFinancialAccount>
 				{{- if .PaymentBIC}}
 				<ram:PayeeSpecifiedCreditorFinancialInstitution>
@@ -138,7 +186,6 @@ const ciiXMLTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 				{{- end}}
 			</ram:SpecifiedTradeSettlementPaymentMeans>
 			{{- end}}
-			<ram:InvoiceCurrencyCode>{{.CurrencyCode}}</ram:InvoiceCurrencyCode>
 			{{- range .TaxBreakdown}}
 			<ram:ApplicableTradeTax>
 				<ram:CalculatedAmount>{{.TaxAmount}}</ram:CalculatedAmount>
@@ -163,11 +210,16 @@ const ciiXMLTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 				</ram:CategoryTradeTax>
 			</ram:SpecifiedTradeAllowanceCharge>
 			{{- end}}
-			{{- if .PaymentDueDate}}
+			{{- if or .PaymentTerms .PaymentDueDate}}
 			<ram:SpecifiedTradePaymentTerms>
+				{{- if .PaymentTerms}}
+				<ram:Description>{{xe .PaymentTerms}}</ram:Description>
+				{{- end}}
+				{{- if .PaymentDueDate}}
 				<ram:DueDateDateTime>
 					<udt:DateTimeString format="102">{{.PaymentDueDate}}</udt:DateTimeString>
 				</ram:DueDateDateTime>
+				{{- end}}
 			</ram:SpecifiedTradePaymentTerms>
 			{{- end}}
 			<ram:SpecifiedTradeSettlementHeaderMonetarySummation>
@@ -194,6 +246,13 @@ type ciiAddress struct {
 	Country    string
 }
 
+// ciiContact is the DefinedTradeContact of a party (EN16931 and above)
+type ciiContact struct {
+	PersonName string
+	Phone      string
+	Email      string
+}
+
 type ciiTaxLine struct {
 	TaxAmount    string
 	BasisAmount  string
@@ -228,15 +287,26 @@ type ciiData struct {
 	SellerName           string
 	SellerAddress        *ciiAddress
 	SellerTaxID          string
+	SellerLegalID        string
+	SellerLegalScheme    string
+	SellerContact        *ciiContact
 	BuyerName            string
 	BuyerAddress         *ciiAddress
 	BuyerTaxID           string
+	BuyerLegalID         string
+	BuyerLegalScheme     string
+	BuyerContact         *ciiContact
 	BuyerReference       string
+	ShipToName           string // empty without ship-to contact or in MINIMUM
+	ShipToAddress        *ciiAddress
 	CurrencyCode         string
 	PaymentMeansCode     string
 	PaymentIBAN          string
 	PaymentBIC           string
+	PaymentAccountName   string // EN16931+ only
+	PaymentReference     string
 	PaymentDueDate       string
+	PaymentTerms         string
 	TaxCategoryCode      string
 	UnitCode             string
 	TaxBreakdown         []ciiTaxLine
@@ -272,6 +342,10 @@ func BuildXML(doc *generator.Document, opts Options) ([]byte, error) {
 		return nil, err
 	}
 
+	return renderXML(data)
+}
+
+func renderXML(data *ciiData) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := ciiTmpl.Execute(&buf, data); err != nil {
 		return nil, fmt.Errorf("facturx: XML template: %w", err)
@@ -297,19 +371,28 @@ func buildCIIData(doc *generator.Document, opts Options) (*ciiData, error) {
 		ID:              doc.Ref,
 		IssueDate:       issueDate,
 		SellerName:      doc.Company.Name,
-		SellerTaxID:     opts.SellerTaxID,
+		SellerTaxID:     opts.sellerTaxID(doc),
 		BuyerName:       doc.Customer.Name,
-		BuyerTaxID:      opts.BuyerTaxID,
+		BuyerTaxID:      opts.buyerTaxID(doc),
 		BuyerReference:  opts.BuyerReference,
 		CurrencyCode:    opts.currencyCode(),
-		PaymentMeansCode: opts.paymentMeansCode(),
-		PaymentIBAN:     opts.PaymentIBAN,
-		PaymentBIC:      opts.PaymentBIC,
+		PaymentMeansCode: opts.paymentMeansCode(doc),
+		PaymentIBAN:     opts.paymentIBAN(doc),
+		PaymentBIC:      opts.paymentBIC(doc),
 		PaymentDueDate:  opts.PaymentDueDate,
+		PaymentTerms:    doc.PaymentTerm,
 		TaxCategoryCode: opts.taxCategoryCode(),
 		UnitCode:        opts.itemDefaultUnitCode(),
 	}
 
+	// Legal registrations, and contacts for EN16931 and above.
+	d.SellerLegalID, d.SellerLegalScheme = legalOrganization(doc.Company)
+	d.BuyerLegalID, d.BuyerLegalScheme = legalOrganization(doc.Customer)
+	if isEN16931Plus {
+		d.SellerContact = tradeContact(doc.Company)
+		d.BuyerContact = tradeContact(doc.Customer)
+	}
+
 	// Seller address — MINIMUM only gets CountryID.
 	if doc.Company.Address != nil {
 		a := &ciiAddress{Country: opts.sellerCountryCode(doc)}
@@ -334,6 +417,20 @@ func buildCIIData(doc *generator.Document, opts Options) (*ciiData, error) {
 		d.BuyerAddress = a
 	}
 
+	// Ship-to party — not part of the MINIMUM profile.
+	if doc.ShipTo != nil && profile != ProfileMinimum {
+		d.ShipToName = doc.ShipTo.Name
+		if doc.ShipTo.Address != nil {
+			d.ShipToAddress = &ciiAddress{
+				Address:    doc.ShipTo.Address.Address,
+				Address2:   doc.ShipTo.Address.Address2,
+				PostalCode: doc.ShipTo.Address.PostalCode,
+				City:       doc.ShipTo.Address.City,
+				Country:    opts.shipToCountryCode(doc),
+			}
+		}
+	}
+
 	// Monetary totals.
 	lineTotal := doc.TotalWithoutTaxAndWithoutDocumentDiscount()
 	taxBasis := doc.TotalWithoutTax()
@@ -348,12 +445,20 @@ func buildCIIData(doc *generator.Document, opts Options) (*ciiData, error) {
 	// MINIMUM profile omits tax breakdown, payment terms, line total.
 	if profile == ProfileMinimum {
 		d.PaymentDueDate = ""
+		d.PaymentTerms = ""
 		d.PaymentIBAN = ""
 		d.PaymentBIC = ""
 		d.PaymentMeansCode = ""
 		return d, nil
 	}
 
+	if doc.Payment != nil {
+		d.PaymentReference = doc.Payment.Reference
+		if isEN16931Plus {
+			d.PaymentAccountName = doc.Payment.AccountHolder
+		}
+	}
+
 	d.HasLineTotalAmount = true
 	d.TaxBreakdown = buildTaxBreakdown(doc, opts.taxCategoryCode())
 
@@ -590,3 +695,35 @@ func formatDate(date string) (string, error) {
 	}
 	return "", fmt.Errorf("facturx: cannot parse date %q", date)
 }
+
+// legalSchemes are the ISO 6523 ICD codes of the registration schemes
+// CII knows; other schemes may be given as their four digit code directly
+var legalSchemes = map[string]string{
+	"SIREN": "0002",
+	"SIRET": "0009",
+}
+
+// legalOrganization returns the registration number of c and the ISO 6523
+// code of its scheme, empty when unknown
+func legalOrganization(c *generator.Contact) (id, scheme string) {
+	if c == nil || c.RegistrationID == "" {
+		return "", ""
+	}
+	scheme = strings.ToUpper(strings.TrimSpace(c.RegistrationScheme))
+	if code, ok := legalSchemes[scheme]; ok {
+		return c.RegistrationID, code
+	}
+	if icdRe.MatchString(scheme) {
+		return c.RegistrationID, scheme
+	}
+	return c.RegistrationID, ""
+}
+
+// tradeContact returns the contact person, phone and email of c, nil when it
+// has none
+func tradeContact(c *generator.Contact) *ciiContact {
+	if c == nil || (c.ContactPerson == "" && c.Phone == "" && c.Email == "") {
+		return nil
+	}
+	return &ciiContact{PersonName: c.ContactPerson, Phone: c.Phone, Email: c.Email}
+}
@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// acceptanceLabelWidth is the width of the name and date labels of the
+// acceptance block
+const acceptanceLabelWidth float64 = 25
+
+// acceptanceSignatureHeight is the height of the signature box
+const acceptanceSignatureHeight float64 = 25
+
+// Form field types
+const (
+	formFieldText      string = "Tx"
+	formFieldSignature string = "Sig"
+)
+
+// formField is a fillable field added to the PDF when it is written, its
+// rectangle in PDF user space (points, from the bottom left corner)
+type formField struct {
+	kind, name string
+	page       int
+	rect       [4]float64
+}
+
+// addFormField records a field of kind named name over the w × h box at
+// x, y of the current page, x being mirrored in right to left documents
+func (doc *Document) addFormField(kind, name string, x, y, w, h float64) {
+	if !doc.Options.AcceptanceFormFields {
+		return
+	}
+	k := doc.pdf.GetConversionRatio()
+	_, pageHeight := doc.pdf.GetPageSize()
+	x = doc.mirrorX(x, w)
+	doc.formFields = append(doc.formFields, formField{
+		kind: kind,
+		name: name,
+		page: doc.pdf.PageNo(),
+		rect: [4]float64{x * k, (pageHeight - y - h) * k, (x + w) * k, (pageHeight - y) * k},
+	})
+}
+
+// appendAcceptance draws the acceptance block of quotations ("Bon pour
+// accord"): the mention to write, then the name, date and signature areas
+func (doc *Document) appendAcceptance() {
+	st := doc.style()
+	x := doc.rightColumnX()
+	secondary := doc.secondary()
+
+	// Title band
+	y := doc.pdf.GetY() + st.SectionSpacing
+	doc.fillRect(doc.Options.GreyBgColor, x, y, rightColumnWidth, 8)
+	doc.pdf.SetXY(doc.mirrorX(x+2, rightColumnWidth-4), y)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", st.BaseFontSize)
+	doc.labelCell(rightColumnWidth-4, 8, doc.Options.TextAcceptanceTitle, secondary.TextAcceptanceTitle, "L")
+
+	// Mention the customer writes by hand
+	doc.pdf.SetXY(doc.mirrorX(x, rightColumnWidth), y+10)
+	doc.pdf.SetFont(doc.Options.Font, "", st.SmallFontSize)
+	doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+	doc.multiCell(
+		rightColumnWidth,
+		3.5,
+		doc.label(doc.Options.TextAcceptanceMention, secondary.TextAcceptanceMention),
+		"0",
+		"L",
+		false,
+	)
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+
+	// Name and date, written on a line
+	doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+	doc.pdf.SetDrawColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
+	lines := []struct {
+		name, label, secondaryLabel string
+	}{
+		{"acceptance_name", doc.Options.TextAcceptanceName, secondary.TextAcceptanceName},
+		{"acceptance_date", doc.Options.TextAcceptanceDate, secondary.TextAcceptanceDate},
+	}
+	fieldX, fieldW := x+acceptanceLabelWidth, rightColumnWidth-acceptanceLabelWidth
+	for _, line := range lines {
+		y = doc.pdf.GetY() + 2
+		doc.pdf.SetXY(doc.mirrorX(x, acceptanceLabelWidth), y)
+		doc.labelCell(acceptanceLabelWidth, 7, line.label, line.secondaryLabel, "L")
+		lineX := doc.mirrorX(fieldX, fieldW)
+		doc.pdf.Line(lineX, y+7, lineX+fieldW, y+7)
+		doc.addFormField(formFieldText, line.name, fieldX, y, fieldW, 7)
+		doc.pdf.SetY(y + 7)
+	}
+
+	// Signature box
+	y = doc.pdf.GetY() + 2
+	doc.pdf.SetXY(doc.mirrorX(x, rightColumnWidth), y)
+	doc.labelCell(rightColumnWidth, 6, doc.Options.TextAcceptanceSignature, secondary.TextAcceptanceSignature, "L")
+	doc.pdf.Rect(doc.mirrorX(x, rightColumnWidth), y+6, rightColumnWidth, acceptanceSignatureHeight, "D")
+	doc.addFormField(formFieldSignature, "acceptance_signature", x, y+6, rightColumnWidth, acceptanceSignatureHeight)
+	doc.pdf.SetDrawColor(0, 0, 0)
+
+	doc.pdf.SetY(y + 6 + acceptanceSignatureHeight)
+}
+
+// addFormFields adds the recorded form fields to the pages of ctx, as an
+// AcroForm. Fields have an empty appearance and Helvetica as typing font.
+func (doc *Document) addFormFields(ctx *model.Context) error {
+	if len(doc.formFields) == 0 {
+		return nil
+	}
+
+	font, err := ctx.IndRefForNewObject(types.Dict{
+		"Type":     types.Name("Font"),
+		"Subtype":  types.Name("Type1"),
+		"BaseFont": types.Name("Helvetica"),
+		"Encoding": types.Name("WinAnsiEncoding"),
+	})
+	if err != nil {
+		return err
+	}
+
+	fields := types.Array{}
+	for _, f := range doc.formFields {
+		pageDict, pageRef, _, err := ctx.PageDict(f.page, false)
+		if err != nil {
+			return err
+		}
+
+		// Viewers draw the field content themselves, PDF/A requires an
+		// appearance anyway
+		w, h := f.rect[2]-f.rect[0], f.rect[3]-f.rect[1]
+		appearance, err := ctx.NewStreamDictForBuf(nil)
+		if err != nil {
+			return err
+		}
+		appearance.InsertName("Type", "XObject")
+		appearance.InsertName("Subtype", "Form")
+		appearance.Insert("BBox", types.NewNumberArray(0, 0, w, h))
+		if err := appearance.Encode(); err != nil {
+			return err
+		}
+		appearanceRef, err := ctx.IndRefForNewObject(*appearance)
+		if err != nil {
+			return err
+		}
+
+		widget := types.Dict{
+			"Type":    types.Name("Annot"),
+			"Subtype": types.Name("Widget"),
+			"FT":      types.Name(f.kind),
+			"T":       types.StringLiteral(f.name),
+			"Rect":    types.NewNumberArray(f.rect[:]...),
+			"F":       types.Integer(4), // Print
+			"P":       *pageRef,
+			"AP":      types.Dict{"N": *appearanceRef},
+		}
+		if f.kind == formFieldText {
+			widget["DA"] = types.StringLiteral("/Helv 10 Tf 0 g")
+		}
+		widgetRef, err := ctx.IndRefForNewObject(widget)
+		if err != nil {
+			return err
+		}
+
+		annots, err := ctx.DereferenceArray(pageDict["Annots"])
+		if err != nil {
+			return err
+		}
+		pageDict["Annots"] = append(annots, *widgetRef)
+		fields = append(fields, *widgetRef)
+	}
+
+	root, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+	root["AcroForm"] = types.Dict{
+		"Fields": fields,
+		"DA":     types.StringLiteral("/Helv 0 Tf 0 g"),
+		"DR":     types.Dict{"Font": types.Dict{"Helv": *font}},
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"codeberg.org/go-pdf/fpdf"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Appendix is content added after the document pages, e.g. general terms of
+// sale. Page numbers and totals count appendix pages.
+type Appendix struct {
+	// Title is drawn above Text
+	Title string `json:"title,omitempty"`
+
+	// Text is basic HTML (bold, italic, underline, line breaks, centered
+	// paragraphs) flowed over as many pages as needed, starting on a new
+	// page, with the header, footer and pagination of the document
+	Text string `json:"text,omitempty"`
+
+	// PDF is an existing PDF whose pages are merged after the document pages
+	// and the text appendices, when it is written (Render, Bytes and
+	// batches). They get the pagination of the document only.
+	PDF []byte `json:"pdf,omitempty"`
+}
+
+// validateAppendices reports empty appendices and PDFs pdfcpu cannot read
+func (doc *Document) validateAppendices(verr *ValidationError) {
+	for idx, a := range doc.Appendices {
+		path := fmt.Sprintf("appendices[%d]", idx)
+		switch {
+		case a == nil || (len(a.Text) == 0 && len(a.PDF) == 0):
+			verr.add(path, "required", "needs a text or a PDF", nil)
+		case len(a.Text) > 0 && len(a.PDF) > 0:
+			verr.add(path, ErrorCodeInvalid, "has both a text and a PDF", nil)
+		case len(a.PDF) > 0:
+			if _, err := pdfcpuapi.PageCount(bytes.NewReader(a.PDF), pdfcpuConf()); err != nil {
+				verr.add(path+".pdf", ErrorCodeInvalid, "is not a PDF", err)
+			}
+		}
+	}
+}
+
+// countAppendixPages returns the page count of the PDF appendices
+func (doc *Document) countAppendixPages() int {
+	count := 0
+	for _, a := range doc.Appendices {
+		if a == nil || len(a.PDF) == 0 {
+			continue
+		}
+		if n, err := pdfcpuapi.PageCount(bytes.NewReader(a.PDF), pdfcpuConf()); err == nil {
+			count += n
+		}
+	}
+	return count
+}
+
+// appendTextAppendices draws the text appendices, each from a new page
+func (doc *Document) appendTextAppendices() {
+	st := doc.style()
+	pageWidth, _ := doc.pdf.GetPageSize()
+	for _, a := range doc.Appendices {
+		if len(a.Text) == 0 {
+			continue
+		}
+
+		doc.pdf.AddPage()
+		if len(a.Title) > 0 {
+			doc.pdf.SetFont(doc.Options.BoldFont, "B", st.TitleFontSize)
+			doc.cellFormat(pageWidth-2*st.Margin, 10, a.Title, "0", 1, doc.mirrorAlign("L"), false)
+			doc.pdf.SetY(doc.pdf.GetY() + 2)
+		}
+
+		doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+		_, lineHt := doc.pdf.GetFontSize()
+		doc.writeHTML(lineHt*1.4, a.Text)
+	}
+
+	// Merged pages count in the {nb} total of the page numbers
+	if doc.appendixPages > 0 {
+		doc.pdf.RegisterAlias("{nb}", strconv.Itoa(doc.pdf.PageCount()+doc.appendixPages))
+	}
+}
+
+// mergeAppendices appends the pages of the PDF appendices to ctx and numbers
+// them like the document pages
+func (doc *Document) mergeAppendices(ctx *model.Context) error {
+	first := ctx.PageCount + 1
+	for idx, a := range doc.Appendices {
+		if len(a.PDF) == 0 {
+			continue
+		}
+		src, err := pdfcpuapi.ReadValidateAndOptimize(bytes.NewReader(a.PDF), pdfcpuConf())
+		if err != nil {
+			return fmt.Errorf("appendices[%d]: %w", idx, err)
+		}
+		if err := pdfcpu.MergeXRefTables(strconv.Itoa(idx), src, ctx, false, false); err != nil {
+			return fmt.Errorf("appendices[%d]: %w", idx, err)
+		}
+	}
+	if first > ctx.PageCount {
+		return nil
+	}
+
+	dims, err := ctx.PageDims()
+	if err != nil {
+		return err
+	}
+	overlay, err := doc.paginationOverlay(dims[first-1:], first, ctx.PageCount)
+	if err != nil || overlay == nil {
+		return err
+	}
+	wm, err := pdfcpuapi.PDFMultiWatermarkForReadSeeker(bytes.NewReader(overlay), 1, first, "position:bl, scalefactor:1 abs, rotation:0", true, false, types.POINTS)
+	if err != nil {
+		return err
+	}
+	pages := types.IntSet{}
+	for nr := first; nr <= ctx.PageCount; nr++ {
+		pages[nr] = true
+	}
+	return pdfcpuapi.WatermarkContext(ctx, pages, wm)
+}
+
+// paginationOverlay returns a PDF of pages of size dims (in points) with the
+// page numbers the header and the footer draw, from page first of total. It
+// returns nil when the document pages are not numbered.
+func (doc *Document) paginationOverlay(dims []types.Dim, first, total int) ([]byte, error) {
+	header := doc.Header != nil && !doc.Header.UseCustomFunc && doc.Header.Pagination
+	footer := doc.Footer != nil && !doc.Footer.UseCustomFunc && doc.Footer.Pagination
+	if !header && !footer {
+		return nil, nil
+	}
+
+	pdf := doc.pdf
+	defer func() {
+		doc.pdf = pdf
+	}()
+	doc.pdf = doc.createPdf()
+	doc.pdf.SetAutoPageBreak(false, 0)
+
+	for i, dim := range dims {
+		size := fpdf.SizeType{Wd: dim.Width / doc.pdf.GetConversionRatio(), Ht: dim.Height / doc.pdf.GetConversionRatio()}
+		doc.pdf.AddPageFormat("P", size)
+		doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+		if header {
+			doc.pdf.SetFont(doc.Options.Font, "", doc.Header.FontSize)
+			doc.appendPagination(doc.Header, doc.headerPaginationY(doc.Header, size.Wd), first+i)
+		}
+		if footer {
+			doc.pdf.SetFont(doc.Options.Font, "", doc.Footer.FontSize)
+			doc.appendPagination(doc.Footer, doc.footerPaginationY(doc.Footer, size.Wd, size.Ht), first+i)
+		}
+	}
+	doc.pdf.RegisterAlias("{nb}", strconv.Itoa(total))
+
+	var buf bytes.Buffer
+	if err := doc.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,232 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+// BatchOptions configures RenderBatch
+type BatchOptions struct {
+	// Workers is the number of documents rendered at the same time,
+	// runtime.NumCPU() when zero or negative
+	Workers int
+}
+
+// BatchResult is the outcome of rendering one document of a batch
+type BatchResult struct {
+	// Index of the document in the slice given to RenderBatch
+	Index    int
+	Document *Document
+
+	// PDF holds the rendered document, nil when Err is set
+	PDF []byte
+	Err error
+}
+
+// BatchSink receives the result of each document of a batch. Results are
+// delivered one at a time, in completion order. Returning an error stops
+// the batch.
+type BatchSink func(result *BatchResult) error
+
+// RenderBatch renders docs on a bounded pool of workers and hands each
+// result to sink. A document that fails to render is reported through
+// BatchResult.Err and does not stop the batch: RenderBatch only returns
+// early when ctx is done or when sink returns an error.
+//
+// Each distinct logo is decoded once for the whole batch, and each worker
+// reuses its page break probe PDF for the documents that use the default
+// fonts only, so the probe fonts are parsed once per worker. Fonts are not
+// shared otherwise: fpdf cannot reuse a parsed font in another PDF, so the
+// fonts of each output PDF are parsed once per document, and documents with
+// OnPdfInit functions, Fonts or FallbackFonts create their own probe, running
+// their OnPdfInit functions again. The documents must be distinct and must
+// not be rendered elsewhere while the batch runs.
+func RenderBatch(ctx context.Context, docs []*Document, opts BatchOptions, sink BatchSink) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(docs))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	results := make(chan *BatchResult)
+
+	go func() {
+		defer close(jobs)
+		for i := range docs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	logos := newLogoCache()
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cache := newRenderCache(logos)
+			for i := range jobs {
+				result := &BatchResult{Index: i, Document: docs[i]}
+				result.PDF, result.Err = renderBatchDocument(docs[i], cache)
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if err := sink(result); err != nil {
+			// Workers may still be rendering documents of docs
+			cancel()
+			wg.Wait()
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// renderBatchDocument renders doc with cache, turning a panic into an error
+// so that one broken document does not take the whole batch down
+func renderBatchDocument(doc *Document, cache *renderCache) (pdf []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pdf, err = nil, fmt.Errorf("render panicked: %v", r)
+		}
+	}()
+
+	if doc == nil {
+		return nil, fmt.Errorf("nil document")
+	}
+
+	var buf bytes.Buffer
+	if err := doc.render(&buf, cache); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// logoCache holds the logos decoded for a batch, shared by its workers. The
+// templates drawing them are kept serialized: fpdf numbers the images of a
+// template while writing a PDF, so each worker needs its own copy.
+type logoCache struct {
+	mu    sync.Mutex
+	logos map[[sha256.Size]byte]*sharedLogo
+}
+
+// sharedLogo is a logo of a logoCache, decoded once
+type sharedLogo struct {
+	once sync.Once
+
+	// tpl is the serialized template, nil when the logo cannot be decoded
+	tpl []byte
+}
+
+func newLogoCache() *logoCache {
+	return &logoCache{logos: map[[sha256.Size]byte]*sharedLogo{}}
+}
+
+// template returns the serialized template drawing logo, whose hash is key,
+// decoding it on first use
+func (c *logoCache) template(key [sha256.Size]byte, logo []byte) []byte {
+	c.mu.Lock()
+	shared, ok := c.logos[key]
+	if !ok {
+		shared = &sharedLogo{}
+		c.logos[key] = shared
+	}
+	c.mu.Unlock()
+
+	shared.once.Do(func() {
+		if tpl := logoTemplate(logo); tpl != nil {
+			shared.tpl, _ = tpl.Serialize()
+		}
+	})
+	return shared.tpl
+}
+
+// logoTemplate returns a template drawing the raster logo logoHeight high,
+// nil when the logo cannot be decoded
+func logoTemplate(logo []byte) fpdf.Template {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(logo))
+	if err != nil || cfg.Height == 0 {
+		return nil
+	}
+
+	size := fpdf.SizeType{Wd: logoHeight * float64(cfg.Width) / float64(cfg.Height), Ht: logoHeight}
+	failed := false
+	tpl := fpdf.CreateTpl(fpdf.PointType{}, size, "P", "mm", "", func(t *fpdf.Tpl) {
+		opts := fpdf.ImageOptions{ImageType: format}
+		t.RegisterImageOptionsReader("logo", opts, bytes.NewReader(logo))
+		t.ImageOptions("logo", 0, 0, size.Wd, size.Ht, false, opts, 0, "")
+		failed = t.Err()
+	})
+	if failed {
+		return nil
+	}
+	return tpl
+}
+
+// renderCache holds what a batch worker reuses across the documents it
+// renders. It must not be shared between workers: fpdf numbers the shared
+// images while writing each PDF.
+type renderCache struct {
+	probe *fpdf.Fpdf
+	logos map[[sha256.Size]byte]fpdf.Template
+
+	// shared holds the logos decoded by all the workers
+	shared *logoCache
+}
+
+func newRenderCache(shared *logoCache) *renderCache {
+	return &renderCache{logos: map[[sha256.Size]byte]fpdf.Template{}, shared: shared}
+}
+
+// cachedLogo returns a template drawing the raster logo logoHeight high, to
+// be scaled to the logo size, decoded once per batch. It returns nil outside
+// of a batch or when the logo cannot be decoded, in which case the logo is
+// registered directly on the document.
+func (doc *Document) cachedLogo(logo []byte) fpdf.Template {
+	if doc.cache == nil {
+		return nil
+	}
+
+	key := sha256.Sum256(logo)
+	if tpl, ok := doc.cache.logos[key]; ok {
+		return tpl
+	}
+
+	var tpl fpdf.Template
+	if b := doc.cache.shared.template(key, logo); b != nil {
+		if t, err := fpdf.DeserializeTemplate(b); err == nil {
+			tpl = t
+		}
+	}
+
+	doc.cache.logos[key] = tpl
+	return tpl
+}
@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func newBatchTestDocument(t testing.TB, i int, logo []byte) *Document {
+	t.Helper()
+
+	doc, err := New(Invoice, &Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	doc.SetRef("INV-" + string(rune('A'+i%26)))
+	doc.SetCompany(&Contact{Name: "Acme Corp", Logo: logo, Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+	doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "5 Side St", PostalCode: "94105", City: "San Francisco"}})
+	for j := 0; j <= i%30; j++ {
+		doc.AppendItem(&Item{Name: "Service", Description: "Monthly plan", UnitCost: "10", Quantity: "2", Tax: &Tax{Percent: "20"}})
+	}
+	return doc
+}
+
+func TestRenderBatch(t *testing.T) {
+	logo, err := os.ReadFile("../support/example_logo.png")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	docs := make([]*Document, 12)
+	for i := range docs {
+		docs[i] = newBatchTestDocument(t, i*3, logo)
+	}
+	// An invalid document must not abort the batch
+	docs[5].Items[0].UnitCost = "ten"
+
+	seen := map[int]bool{}
+	err = RenderBatch(context.Background(), docs, BatchOptions{Workers: 3}, func(result *BatchResult) error {
+		if seen[result.Index] {
+			t.Errorf("document %d reported twice", result.Index)
+		}
+		seen[result.Index] = true
+
+		if result.Document != docs[result.Index] {
+			t.Errorf("document %d: unexpected Document", result.Index)
+		}
+
+		if result.Index == 5 {
+			var verr *ValidationError
+			if !errors.As(result.Err, &verr) {
+				t.Errorf("document 5: expected a validation error, got %v", result.Err)
+			}
+			return nil
+		}
+
+		if result.Err != nil {
+			t.Errorf("document %d: %v", result.Index, result.Err)
+			return nil
+		}
+		if err := pdfcpuapi.Validate(bytes.NewReader(result.PDF), nil); err != nil {
+			t.Errorf("document %d: invalid pdf: %v", result.Index, err)
+		}
+		if !bytes.Contains(result.PDF, []byte("/Subtype /Image")) {
+			t.Errorf("document %d: logo not drawn", result.Index)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RenderBatch: %v", err)
+	}
+	if len(seen) != len(docs) {
+		t.Fatalf("got %d results, want %d", len(seen), len(docs))
+	}
+
+	// Batch and single renders lay out the same pages
+	single, err := docs[3].Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := docs[3].render(&buf, newRenderCache(newLogoCache())); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if single.PageCount() != docs[3].Pdf().PageCount() {
+		t.Fatalf("batch render has %d pages, want %d", docs[3].Pdf().PageCount(), single.PageCount())
+	}
+}
+
+func TestRenderBatchSinkError(t *testing.T) {
+	docs := make([]*Document, 8)
+	for i := range docs {
+		docs[i] = newBatchTestDocument(t, i, nil)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := RenderBatch(context.Background(), docs, BatchOptions{Workers: 2}, func(*BatchResult) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected sink error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("sink called %d times after returning an error", calls)
+	}
+
+	// The workers are done with the documents, which the race detector checks
+	for _, doc := range docs {
+		doc.SetRef("INV-STOP")
+	}
+}
+
+func TestRenderBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	docs := []*Document{newBatchTestDocument(t, 0, nil)}
+	err := RenderBatch(ctx, docs, BatchOptions{}, func(*BatchResult) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func BenchmarkRenderSequential(b *testing.B) {
+	logo, _ := os.ReadFile("../support/example_logo.png")
+	docs := make([]*Document, 20)
+	for i := range docs {
+		docs[i] = newBatchTestDocument(b, i, logo)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		for _, doc := range docs {
+			if _, err := doc.Bytes(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRenderBatch(b *testing.B) {
+	logo, _ := os.ReadFile("../support/example_logo.png")
+	docs := make([]*Document, 20)
+	for i := range docs {
+		docs[i] = newBatchTestDocument(b, i, logo)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		if err := RenderBatch(context.Background(), docs, BatchOptions{Workers: 1}, func(r *BatchResult) error {
+			return r.Err
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package generator
+
+import "strings"
+
+// bilingualSecondaryFontRatio is the size of stacked secondary labels
+// relative to the primary label font size
+const bilingualSecondaryFontRatio float64 = 0.75
+
+// secondary returns the secondary locale options. Documents without a
+// secondary locale get empty options so every secondary label is blank.
+func (doc *Document) secondary() *Options {
+	if doc.Options.Secondary == nil {
+		return &Options{}
+	}
+	return doc.Options.Secondary
+}
+
+// isBilingualLabel reports whether secondary must be rendered next to primary
+func isBilingualLabel(primary, secondary string) bool {
+	return len(secondary) > 0 && secondary != primary
+}
+
+// label joins primary and its secondary translation on a single line
+func (doc *Document) label(primary, secondary string) string {
+	if !isBilingualLabel(primary, secondary) {
+		return primary
+	}
+	return primary + doc.Options.BilingualSeparator + secondary
+}
+
+// labelCell draws a label cell of w × h at the current position, like
+// CellFormat without border, fill or line break. Bilingual labels are stacked
+// (secondary under primary, in a smaller font) when the layout asks for it or
+// when the inline label would not fit in w. Alignments are mirrored in right
+// to left documents.
+func (doc *Document) labelCell(w, h float64, primary, secondary, alignStr string) {
+	alignStr = doc.mirrorAlign(alignStr)
+	inline := doc.encodeString(doc.label(primary, secondary))
+	if !isBilingualLabel(primary, secondary) ||
+		(doc.Options.BilingualLayout != BilingualStacked && doc.textWidth(inline) <= w) {
+		doc.drawCell(w, h, inline, "0", 0, alignStr, false)
+		return
+	}
+
+	hAlign := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("TMBA", r) {
+			return -1
+		}
+		return r
+	}, alignStr)
+
+	x, y := doc.pdf.GetXY()
+	fontSize, _ := doc.pdf.GetFontSize()
+	r, g, b := doc.pdf.GetTextColor()
+
+	doc.cellFormat(w, h/2, primary, "0", 0, hAlign+"B", false)
+
+	doc.pdf.SetXY(x, y+h/2)
+	doc.pdf.SetFontSize(fontSize * bilingualSecondaryFontRatio)
+	doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+	doc.cellFormat(w, h/2, secondary, "0", 0, hAlign+"T", false)
+
+	doc.pdf.SetFontSize(fontSize)
+	doc.pdf.SetTextColor(r, g, b)
+	doc.pdf.SetXY(x+w, y)
+}
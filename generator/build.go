@@ -2,23 +2,106 @@ package generator
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"codeberg.org/go-pdf/fpdf"
+	"github.com/creasty/defaults"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/shopspring/decimal"
 )
 
-// Build pdf document from data provided
+// ErrRenderRequired is returned by Build for documents with a letterhead,
+// acceptance form fields or PDF appendices, which are added when the PDF is
+// written: use Render, Bytes or RenderBatch instead.
+var ErrRenderRequired = errors.New("document needs Render or Bytes: letterhead, form fields or PDF appendices are added when the PDF is written")
+
+// Build pdf document from data provided. Each call renders into a new
+// *fpdf.Fpdf, so a document can be built several times.
+//
+// Letterhead backgrounds, acceptance form fields and PDF appendices are not
+// fpdf content: Build returns ErrRenderRequired for documents that have any.
 func (doc *Document) Build() (*fpdf.Fpdf, error) {
+	if doc.needsPostProcess() {
+		return nil, ErrRenderRequired
+	}
+	return doc.build(nil)
+}
+
+// needsPostProcess reports whether the document has a letterhead, acceptance
+// form fields or PDF appendices, added to the PDF once written by fpdf
+func (doc *Document) needsPostProcess() bool {
+	if doc.Options.Letterhead != nil {
+		return true
+	}
+	if doc.Type == Quotation && doc.Options.Acceptance && doc.Options.AcceptanceFormFields {
+		return true
+	}
+	for _, a := range doc.Appendices {
+		if a != nil && len(a.PDF) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// build renders the document, reusing the probe PDF and logos of cache when
+// not nil. Documents whose header or footer Func needs the page count are
+// rendered twice, the first pass counting the pages.
+func (doc *Document) build(cache *renderCache) (*fpdf.Fpdf, error) {
+	doc.pageTotal = 0
+	doc.appendixPages = doc.countAppendixPages()
+
+	pdf, err := doc.buildPages(cache)
+	if err != nil || (!doc.Header.hasFunc() && !doc.Footer.hasFunc()) {
+		return pdf, err
+	}
+
+	doc.pageTotal = pdf.PageCount() + doc.appendixPages
+	return doc.buildPages(cache)
+}
+
+// buildPages renders the pages of the document
+func (doc *Document) buildPages(cache *renderCache) (*fpdf.Fpdf, error) {
+	doc.cache = cache
+	defer func() {
+		doc.cache = nil
+		doc.probe = nil
+		doc.tableColumns = nil
+	}()
+
 	// Validate document data
 	if err := doc.Validate(); err != nil {
 		return nil, err
 	}
 
+	if doc.Watermark != nil {
+		if err := defaults.Set(doc.Watermark); err != nil {
+			return nil, err
+		}
+	}
+
+	// Kept after the render, like the fonts
+	doc.letterheadStyle = nil
+	if doc.Options.Letterhead != nil {
+		doc.letterheadStyle = doc.Options.Letterhead.style(doc.Options.Style)
+	}
+
+	doc.formFields = nil
+
+	doc.pdf = doc.newPdf()
+
+	// Kept after the render: the footer of the last page is drawn when the
+	// PDF is closed
+	doc.fonts = doc.newFontSet()
+
 	// Build base doc
-	doc.pdf.SetMargins(BaseMargin, BaseMarginTop, BaseMargin)
-	doc.pdf.SetXY(10, 10)
+	st := doc.style()
+	doc.pdf.SetMargins(st.Margin, st.MarginTop, st.Margin)
+	doc.pdf.SetAutoPageBreak(true, st.MarginBottom)
+	doc.pdf.SetXY(st.Margin, st.Margin)
 	doc.pdf.SetTextColor(
 		doc.Options.BaseTextColor[0],
 		doc.Options.BaseTextColor[1],
@@ -30,6 +113,8 @@ func (doc *Document) Build() (*fpdf.Fpdf, error) {
 		if err := doc.Header.applyHeader(doc); err != nil {
 			return nil, err
 		}
+	} else {
+		doc.pdf.SetHeaderFunc(doc.beginPage)
 	}
 
 	// Set footer
@@ -37,6 +122,8 @@ func (doc *Document) Build() (*fpdf.Fpdf, error) {
 		if err := doc.Footer.applyFooter(doc); err != nil {
 			return nil, err
 		}
+	} else {
+		doc.pdf.SetFooterFunc(doc.endPage)
 	}
 
 	// Add first page
@@ -45,23 +132,16 @@ func (doc *Document) Build() (*fpdf.Fpdf, error) {
 	// Load font
 	doc.pdf.SetFont(doc.Options.Font, "", 12)
 
+	theme := doc.theme()
+
 	// Appenf document title
-	doc.appendTitle()
+	theme.Title(doc)
 
 	// Appenf document metas (ref & version)
-	doc.appendMetas()
-
-	// Append company contact to doc
-	companyBottom := doc.Company.appendCompanyContactToDoc(doc)
-
-	// Append customer contact to doc
-	customerBottom := doc.Customer.appendCustomerContactToDoc(doc)
+	theme.Metas(doc)
 
-	if customerBottom > companyBottom {
-		doc.pdf.SetXY(10, customerBottom)
-	} else {
-		doc.pdf.SetXY(10, companyBottom)
-	}
+	// Append company and customer contacts to doc
+	theme.Contacts(doc)
 
 	// Append description
 	doc.appendDescription()
@@ -69,236 +149,356 @@ func (doc *Document) Build() (*fpdf.Fpdf, error) {
 	// Append items
 	doc.appendItems()
 
-	// Total and payment term share the right column and must stay together.
+	// Total, payment term and payment instructions share the right column
+	// and must stay together.
 	doc.pageTxn(func(d *Document) {
 		// Notes resets Y after rendering (left column, side-by-side with total).
-		d.appendNotes()
+		theme.Notes(d)
 
-		d.appendTotal()
-		d.appendPaymentTerm()
+		theme.Totals(d)
+		d.appendAmountInWords()
+		theme.PaymentTerm(d)
+		d.appendPayment()
 	})
 
+	// The acceptance block of quotations stays on one page
+	if doc.Type == Quotation && doc.Options.Acceptance {
+		doc.pageTxn(func(d *Document) {
+			d.appendAcceptance()
+		})
+	}
+
+	doc.appendTextAppendices()
+
 	return doc.pdf, nil
 }
 
-// appendTitle to document
-func (doc *Document) appendTitle() {
-	title := doc.typeAsString()
+// Render builds the document into a new PDF and writes it to w
+func (doc *Document) Render(w io.Writer) error {
+	return doc.render(w, nil)
+}
+
+// Bytes builds the document into a new PDF and returns its content
+func (doc *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := doc.render(&buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (doc *Document) render(w io.Writer, cache *renderCache) error {
+	pdf, err := doc.build(cache)
+	if err != nil {
+		return err
+	}
+	if doc.Options.Letterhead == nil && doc.appendixPages == 0 && len(doc.formFields) == 0 {
+		return pdf.Output(w)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return err
+	}
+	return doc.postProcess(buf.Bytes(), w)
+}
+
+// postProcess writes pdf to w with the letterhead backgrounds, the form
+// fields and the PDF appendices
+func (doc *Document) postProcess(pdf []byte, w io.Writer) error {
+	conf := pdfcpuConf()
+	ctx, err := pdfcpuapi.ReadValidateAndOptimize(bytes.NewReader(pdf), conf)
+	if err != nil {
+		return fmt.Errorf("read PDF: %w", err)
+	}
+
+	if l := doc.Options.Letterhead; l != nil {
+		if err := l.underlay(ctx); err != nil {
+			return fmt.Errorf("letterhead: %w", err)
+		}
+	}
+	if err := doc.addFormFields(ctx); err != nil {
+		return fmt.Errorf("form fields: %w", err)
+	}
+	if err := doc.mergeAppendices(ctx); err != nil {
+		return err
+	}
+
+	if err := pdfaOptionalContent(ctx); err != nil {
+		return err
+	}
+	return pdfcpuapi.Write(ctx, w, conf)
+}
+
+// appendTitle to document, on a bg filled band (none when nil) in textColor
+// and fontStyle
+func (doc *Document) appendTitle(bg, textColor []int, fontStyle string) {
+	st := doc.style()
+	x := doc.rightColumnX()
 
 	// Set x y
-	doc.pdf.SetXY(120, BaseMarginTop)
+	doc.pdf.SetXY(doc.mirrorX(x, rightColumnWidth), st.MarginTop)
 
 	// Draw rect
-	doc.pdf.SetFillColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
-	doc.pdf.Rect(120, BaseMarginTop, 80, 10, "F")
+	doc.fillRect(bg, x, st.MarginTop, rightColumnWidth, 10)
 
 	// Draw text
-	doc.pdf.SetFont(doc.Options.Font, "", 14)
-	doc.pdf.CellFormat(80, 10, doc.encodeString(title), "0", 0, "C", false, 0, "")
+	font := doc.Options.Font
+	if len(fontStyle) > 0 {
+		font = doc.Options.BoldFont
+	}
+	doc.pdf.SetFont(font, fontStyle, st.TitleFontSize)
+	doc.pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+	doc.labelCell(rightColumnWidth, 10, doc.typeAsString(doc.Options), doc.typeAsString(doc.secondary()), "C")
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
 }
 
 // appendMetas to document
 func (doc *Document) appendMetas() {
 	// Append ref
-	refString := fmt.Sprintf("%s: %s", doc.Options.TextRefTitle, doc.Ref)
+	st := doc.style()
+	x := doc.rightColumnX()
+	secondary := doc.secondary()
+	refString := fmt.Sprintf("%s: %s", doc.label(doc.Options.TextRefTitle, secondary.TextRefTitle), doc.Ref)
 
-	doc.pdf.SetXY(120, BaseMarginTop+11)
-	doc.pdf.SetFont(doc.Options.Font, "", 8)
-	doc.pdf.CellFormat(80, 4, doc.encodeString(refString), "0", 0, "R", false, 0, "")
+	x = doc.mirrorX(x, rightColumnWidth)
+	doc.pdf.SetXY(x, st.MarginTop+11)
+	doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+	doc.cellFormat(rightColumnWidth, 4, refString, "0", 0, doc.mirrorAlign("R"), false)
 
 	// Append version
 	if len(doc.Version) > 0 {
-		versionString := fmt.Sprintf("%s: %s", doc.Options.TextVersionTitle, doc.Version)
-		doc.pdf.SetXY(120, BaseMarginTop+15)
-		doc.pdf.SetFont(doc.Options.Font, "", 8)
-		doc.pdf.CellFormat(80, 4, doc.encodeString(versionString), "0", 0, "R", false, 0, "")
+		versionString := fmt.Sprintf("%s: %s", doc.label(doc.Options.TextVersionTitle, secondary.TextVersionTitle), doc.Version)
+		doc.pdf.SetXY(x, st.MarginTop+15)
+		doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+		doc.cellFormat(rightColumnWidth, 4, versionString, "0", 0, doc.mirrorAlign("R"), false)
 	}
 
 	// Append date
-	date := time.Now().Format("02/01/2006")
+	dateString := fmt.Sprintf("%s: %s", doc.label(doc.Options.TextDateTitle, secondary.TextDateTitle), doc.date())
+	doc.pdf.SetXY(x, st.MarginTop+19)
+	doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+	doc.cellFormat(rightColumnWidth, 4, dateString, "0", 0, doc.mirrorAlign("R"), false)
+}
+
+// date returns the document date, today when Date is empty
+func (doc *Document) date() string {
 	if len(doc.Date) > 0 {
-		date = doc.Date
+		return doc.Date
 	}
-	dateString := fmt.Sprintf("%s: %s", doc.Options.TextDateTitle, date)
-	doc.pdf.SetXY(120, BaseMarginTop+19)
-	doc.pdf.SetFont(doc.Options.Font, "", 8)
-	doc.pdf.CellFormat(80, 4, doc.encodeString(dateString), "0", 0, "R", false, 0, "")
+	return time.Now().Format("02/01/2006")
 }
 
 // appendDescription to document
 func (doc *Document) appendDescription() {
 	if len(doc.Description) > 0 {
-		doc.pdf.SetY(doc.pdf.GetY() + 10)
-		doc.pdf.SetFont(doc.Options.Font, "", 10)
-		doc.pdf.MultiCell(190, 5, doc.encodeString(doc.Description), "B", "L", false)
+		st := doc.style()
+		doc.pdf.SetY(doc.pdf.GetY() + st.SectionSpacing)
+		doc.pdf.SetFont(doc.Options.Font, "", st.LargeFontSize)
+		left, right := doc.tableBounds()
+		doc.multiCell(right-left, 5, doc.Description, "B", "L", false)
 	}
 }
 
-// drawsTableTitles in document
-func (doc *Document) drawsTableTitles() {
+// drawsTableTitles in document, on a bg filled band (none when nil) in
+// textColor
+func (doc *Document) drawsTableTitles(bg, textColor []int) {
+	st := doc.style()
+	left, right := doc.tableBounds()
+
 	// Draw table titles
-	doc.pdf.SetX(10)
+	doc.pdf.SetX(st.Margin)
 	doc.pdf.SetY(doc.pdf.GetY() + 5)
-	doc.pdf.SetFont(doc.Options.BoldFont, "B", 8)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", st.BaseFontSize)
+	doc.pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
 
 	// Draw rec
-	doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-	doc.pdf.Rect(10, doc.pdf.GetY(), 190, 6, "F")
-
-	// Name
-	doc.pdf.SetX(ItemColNameOffset)
-	doc.pdf.CellFormat(
-		ItemColUnitPriceOffset-ItemColNameOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsNameTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
-
-	// Unit price
-	doc.pdf.SetX(ItemColUnitPriceOffset)
-	doc.pdf.CellFormat(
-		ItemColQuantityOffset-ItemColUnitPriceOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsUnitCostTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+	doc.fillRect(bg, left, doc.pdf.GetY(), right-left, 6)
 
-	// Quantity
-	doc.pdf.SetX(ItemColQuantityOffset)
-	doc.pdf.CellFormat(
-		ItemColTaxOffset-ItemColQuantityOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsQuantityTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+	for _, col := range doc.tableColumns {
+		doc.pdf.SetX(doc.mirrorX(col.X, col.W))
+		doc.labelCell(col.W, 6, col.Label, col.SecondaryLabel, col.Align)
+	}
 
-	// Total HT
-	doc.pdf.SetX(ItemColTotalHTOffset)
-	doc.pdf.CellFormat(
-		ItemColTaxOffset-ItemColTotalHTOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsTotalHTTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+}
 
-	// Tax
-	doc.pdf.SetX(ItemColTaxOffset)
-	doc.pdf.CellFormat(
-		ItemColDiscountOffset-ItemColTaxOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsTaxTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+// rightColumnWidth is the width of the right column holding the title,
+// the metas, the customer contact and the totals
+const rightColumnWidth float64 = 80
 
-	// Discount
-	doc.pdf.SetX(ItemColDiscountOffset)
-	doc.pdf.CellFormat(
-		ItemColTotalTTCOffset-ItemColDiscountOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsDiscountTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+// rightColumnX returns the left X of the right column
+func (doc *Document) rightColumnX() float64 {
+	pageWidth, _ := doc.pdf.GetPageSize()
+	return pageWidth - doc.style().Margin - rightColumnWidth
+}
 
-	// TOTAL TTC
-	doc.pdf.SetX(ItemColTotalTTCOffset)
-	doc.pdf.CellFormat(
-		190-ItemColTotalTTCOffset,
-		6,
-		doc.encodeString(doc.Options.TextItemsTotalTTCTitle),
-		"0",
-		0,
-		"",
-		false,
-		0,
-		"",
-	)
+// tableBounds returns the left and right X of the items table
+func (doc *Document) tableBounds() (left, right float64) {
+	pageWidth, _ := doc.pdf.GetPageSize()
+	return doc.style().Margin, pageWidth - doc.style().Margin
 }
 
 // appendItems to document
 func (doc *Document) appendItems() {
-	doc.drawsTableTitles()
-
-	doc.pdf.SetX(10)
-	doc.pdf.SetY(doc.pdf.GetY() + 8)
-	doc.pdf.SetFont(doc.Options.Font, "", 8)
+	st := doc.style()
+	theme := doc.theme()
+	left, right := doc.tableBounds()
+	doc.tableColumns = doc.layoutColumns(left, right)
+
+	theme.TableHeader(doc)
+
+	// With running subtotals, rows stop early enough for the carried forward
+	// row to fit at the bottom of the page
+	ft := &forwardTotals{}
+	var reserve float64
+	if doc.Options.CarriedForward {
+		reserve = forwardRowHeight + 2*st.ItemRowPadding
+	}
+	secondary := doc.secondary()
 
 	for _, item := range doc.Items {
-		doc.pageTxn(func(d *Document) {
-			item.appendColTo(d)
-		}, func(d *Document) {
-			d.drawsTableTitles()
-			d.pdf.SetFont(d.Options.Font, "", 8)
-			d.pdf.SetX(10)
-			d.pdf.SetY(d.pdf.GetY() + 8)
+		forward := doc.Options.CarriedForward && ft.count > 0
+		var carried pageTxnFn
+		if forward {
+			carried = func(d *Document) {
+				d.appendForwardRow(d.Options.TextCarriedForward, secondary.TextCarriedForward, ft)
+			}
+		}
+		doc.reservedPageTxn(func(d *Document) {
+			theme.Item(d, item)
+		}, reserve, carried, func(d *Document) {
+			theme.TableHeader(d)
+			if forward {
+				d.appendForwardRow(d.Options.TextBroughtForward, secondary.TextBroughtForward, ft)
+				d.pdf.SetY(d.pdf.GetY() + st.ItemRowPadding)
+			}
 		})
+		ft.add(item)
 
 		// Gray separator line at the bottom of the item row
-		doc.pdf.SetY(doc.pdf.GetY() + 3)
+		doc.pdf.SetY(doc.pdf.GetY() + st.ItemRowPadding)
 		lineY := doc.pdf.GetY()
 		doc.pdf.SetDrawColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-		doc.pdf.Line(10, lineY, 200, lineY)
+		doc.pdf.Line(left, lineY, right, lineY)
 		doc.pdf.SetDrawColor(0, 0, 0)
 
-		doc.pdf.SetX(10)
-		doc.pdf.SetY(doc.pdf.GetY() + 3)
+		doc.pdf.SetX(st.Margin)
+		doc.pdf.SetY(doc.pdf.GetY() + st.ItemRowPadding)
 	}
 }
 
+// appendContacts draws the company and customer contacts side by side, the
+// ship-to contact below the customer, and moves below the lowest one
+func (doc *Document) appendContacts(fill bool) {
+	companyBottom := doc.Company.appendCompanyContactToDoc(doc, fill)
+	primary, other, title, secondaryTitle := doc.recipients()
+	customerBottom := primary.appendCustomerContactToDoc(doc, fill)
+	if other != nil {
+		customerBottom = other.appendContactBelowCustomer(doc, fill, customerBottom, title, secondaryTitle)
+	}
+
+	doc.pdf.SetXY(doc.style().Margin, max(companyBottom, customerBottom))
+}
+
+// appendTableHeader draws the items table header and moves to the first row
+func (doc *Document) appendTableHeader(bg, textColor []int) {
+	st := doc.style()
+	doc.drawsTableTitles(bg, textColor)
+	doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+	doc.pdf.SetX(st.Margin)
+	doc.pdf.SetY(doc.pdf.GetY() + 8)
+}
+
 // appendNotes to document
 func (doc *Document) appendNotes() {
 	if len(doc.Notes) == 0 {
 		return
 	}
 
+	st := doc.style()
 	currentY := doc.pdf.GetY()
 
-	doc.pdf.SetFont(doc.Options.Font, "", 9)
-	doc.pdf.SetX(BaseMargin)
-	doc.pdf.SetRightMargin(100)
-	doc.pdf.SetY(currentY + 10)
+	doc.pdf.SetFont(doc.Options.Font, "", st.NotesFontSize)
+	// Notes end 10 mm before the totals block
+	pageWidth, _ := doc.pdf.GetPageSize()
+	notesMargin := pageWidth - doc.rightColumnX() + 10
+	if doc.isRTL() {
+		doc.pdf.SetLeftMargin(notesMargin)
+	} else {
+		doc.pdf.SetRightMargin(notesMargin)
+	}
+	doc.pdf.SetY(currentY + st.SectionSpacing)
+	doc.pdf.SetX(doc.mirrorX(st.Margin, 0))
 
 	_, lineHt := doc.pdf.GetFontSize()
-	html := doc.pdf.HTMLBasicNew()
-	html.Write(lineHt, doc.encodeString(doc.Notes))
+	doc.writeHTML(lineHt, doc.Notes)
 
-	doc.pdf.SetRightMargin(BaseMargin)
+	doc.pdf.SetLeftMargin(st.Margin)
+	doc.pdf.SetRightMargin(st.Margin)
 	doc.pdf.SetY(currentY)
 }
 
-// appendTotal to document
-func (doc *Document) appendTotal() {
-	doc.pdf.SetY(doc.pdf.GetY() + 10)
-	doc.pdf.SetFont(doc.Options.Font, "", LargeTextFontSize)
+// totalsStyle holds the colors of the totals block. Nil colors are not filled.
+type totalsStyle struct {
+	labelBg, amountBg []int
+
+	// Colors of the total with tax row
+	totalLabelBg, totalAmountBg, totalTextColor []int
+	totalBold                                   bool
+}
+
+// discountDescription describes the document discount as both a
+// percentage and an amount ("-10 % / -12.00 €")
+func (doc *Document) discountDescription() string {
+	var descString bytes.Buffer
+	discountType, discountAmount := doc.Discount.getDiscount()
+	if discountType == DiscountTypePercent {
+		descString.WriteString("-")
+		descString.WriteString(discountAmount.String())
+		descString.WriteString(" % / -")
+		descString.WriteString(doc.ac.FormatMoneyDecimal(
+			doc.TotalWithoutTaxAndWithoutDocumentDiscount().Sub(doc.TotalWithoutTax())),
+		)
+	} else {
+		descString.WriteString("-")
+		descString.WriteString(doc.ac.FormatMoneyDecimal(discountAmount))
+		descString.WriteString(" / -")
+		descString.WriteString(
+			discountAmount.Mul(decimal.NewFromFloat(100)).Div(doc.TotalWithoutTaxAndWithoutDocumentDiscount()).StringFixed(2),
+		)
+		descString.WriteString(" %")
+	}
+	return descString.String()
+}
+
+// classicTotalsStyle returns the totals colors of the classic theme
+func (doc *Document) classicTotalsStyle() totalsStyle {
+	return totalsStyle{
+		labelBg:       doc.Options.DarkBgColor,
+		amountBg:      doc.Options.GreyBgColor,
+		totalLabelBg:  doc.Options.DarkBgColor,
+		totalAmountBg: doc.Options.GreyBgColor,
+	}
+}
+
+// fillRect fills a rectangle with color, when not nil. x is mirrored in
+// right to left documents.
+func (doc *Document) fillRect(color []int, x, y, w, h float64) {
+	if color == nil {
+		return
+	}
+	doc.pdf.SetFillColor(color[0], color[1], color[2])
+	doc.pdf.Rect(doc.mirrorX(x, w), y, w, h, "F")
+}
+
+// appendTotal to document, leaving Y at the bottom of the totals block
+func (doc *Document) appendTotal(ts totalsStyle) {
+	st := doc.style()
+	x := doc.rightColumnX()
+	secondary := doc.secondary()
+	doc.pdf.SetY(doc.pdf.GetY() + st.SectionSpacing)
+	doc.pdf.SetFont(doc.Options.Font, "", st.LargeFontSize)
 	doc.pdf.SetTextColor(
 		doc.Options.BaseTextColor[0],
 		doc.Options.BaseTextColor[1],
@@ -306,69 +506,37 @@ func (doc *Document) appendTotal() {
 	)
 
 	// Draw TOTAL HT title
-	doc.pdf.SetX(120)
-	doc.pdf.SetFillColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
-	doc.pdf.Rect(120, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(38, 10, doc.encodeString(doc.Options.TextTotalTotal), "0", 0, "R", false, 0, "")
+	doc.pdf.SetX(doc.mirrorX(x, 38))
+	doc.fillRect(ts.labelBg, x, doc.pdf.GetY(), 40, 10)
+	doc.labelCell(38, 10, doc.Options.TextTotalTotal, secondary.TextTotalTotal, "R")
 
 	// Draw TOTAL HT amount
-	doc.pdf.SetX(162)
-	doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-	doc.pdf.Rect(160, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(
-		40,
-		10,
-		doc.encodeString(doc.ac.FormatMoneyDecimal(doc.TotalWithoutTaxAndWithoutDocumentDiscount())),
-		"0",
-		0,
-		"L",
-		false,
-		0,
-		"",
-	)
+	doc.pdf.SetX(doc.mirrorX(x+42, 40))
+	doc.fillRect(ts.amountBg, x+40, doc.pdf.GetY(), 40, 10)
+	doc.cellFormat(40, 10, doc.ac.FormatMoneyDecimal(doc.TotalWithoutTaxAndWithoutDocumentDiscount()), "0", 0, doc.mirrorAlign("L"), false)
 
 	if doc.Discount != nil {
 		baseY := doc.pdf.GetY() + 10
 
 		// Draw discounted title
-		doc.pdf.SetXY(120, baseY)
-		doc.pdf.SetFillColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
-		doc.pdf.Rect(120, doc.pdf.GetY(), 40, 15, "F")
+		doc.pdf.SetXY(doc.mirrorX(x, 38), baseY)
+		doc.fillRect(ts.labelBg, x, doc.pdf.GetY(), 40, 15)
 
 		// title
-		doc.pdf.CellFormat(38, 7.5, doc.encodeString(doc.Options.TextTotalDiscounted), "0", 0, "BR", false, 0, "")
+		doc.labelCell(38, 7.5, doc.Options.TextTotalDiscounted, secondary.TextTotalDiscounted, "BR")
 
 		// description
-		doc.pdf.SetXY(120, baseY+7.5)
-		doc.pdf.SetFont(doc.Options.Font, "", BaseTextFontSize)
+		doc.pdf.SetXY(doc.mirrorX(x, 38), baseY+7.5)
+		doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
 		doc.pdf.SetTextColor(
 			doc.Options.GreyTextColor[0],
 			doc.Options.GreyTextColor[1],
 			doc.Options.GreyTextColor[2],
 		)
 
-		var descString bytes.Buffer
-		discountType, discountAmount := doc.Discount.getDiscount()
-		if discountType == DiscountTypePercent {
-			descString.WriteString("-")
-			descString.WriteString(discountAmount.String())
-			descString.WriteString(" % / -")
-			descString.WriteString(doc.ac.FormatMoneyDecimal(
-				doc.TotalWithoutTaxAndWithoutDocumentDiscount().Sub(doc.TotalWithoutTax())),
-			)
-		} else {
-			descString.WriteString("-")
-			descString.WriteString(doc.ac.FormatMoneyDecimal(discountAmount))
-			descString.WriteString(" / -")
-			descString.WriteString(
-				discountAmount.Mul(decimal.NewFromFloat(100)).Div(doc.TotalWithoutTaxAndWithoutDocumentDiscount()).StringFixed(2),
-			)
-			descString.WriteString(" %")
-		}
-
-		doc.pdf.CellFormat(38, 7.5, doc.encodeString(descString.String()), "0", 0, "TR", false, 0, "")
+		doc.cellFormat(38, 7.5, doc.discountDescription(), "0", 0, doc.mirrorAlign("TR"), false)
 
-		doc.pdf.SetFont(doc.Options.Font, "", LargeTextFontSize)
+		doc.pdf.SetFont(doc.Options.Font, "", st.LargeFontSize)
 		doc.pdf.SetTextColor(
 			doc.Options.BaseTextColor[0],
 			doc.Options.BaseTextColor[1],
@@ -377,81 +545,91 @@ func (doc *Document) appendTotal() {
 
 		// Draw discount amount
 		doc.pdf.SetY(baseY)
-		doc.pdf.SetX(162)
-		doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-		doc.pdf.Rect(160, doc.pdf.GetY(), 40, 15, "F")
-		doc.pdf.CellFormat(
-			40,
-			15,
-			doc.encodeString(doc.ac.FormatMoneyDecimal(doc.TotalWithoutTax())),
-			"0",
-			0,
-			"L",
-			false,
-			0,
-			"",
-		)
+		doc.pdf.SetX(doc.mirrorX(x+42, 40))
+		doc.fillRect(ts.amountBg, x+40, doc.pdf.GetY(), 40, 15)
+		doc.cellFormat(40, 15, doc.ac.FormatMoneyDecimal(doc.TotalWithoutTax()), "0", 0, doc.mirrorAlign("L"), false)
 		doc.pdf.SetY(doc.pdf.GetY() + 15)
 	} else {
 		doc.pdf.SetY(doc.pdf.GetY() + 10)
 	}
 
 	// Draw main TAX line (always same size).
-	doc.pdf.SetX(120)
-	doc.pdf.SetFillColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
-	doc.pdf.Rect(120, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(38, 10, doc.encodeString(doc.Options.TextTotalTax), "0", 0, "R", false, 0, "")
-	doc.pdf.SetX(162)
-	doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-	doc.pdf.Rect(160, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(40, 10, doc.encodeString(doc.ac.FormatMoneyDecimal(doc.Tax())), "0", 0, "L", false, 0, "")
+	doc.pdf.SetX(doc.mirrorX(x, 38))
+	doc.fillRect(ts.labelBg, x, doc.pdf.GetY(), 40, 10)
+	doc.labelCell(38, 10, doc.Options.TextTotalTax, secondary.TextTotalTax, "R")
+	doc.pdf.SetX(doc.mirrorX(x+42, 40))
+	doc.fillRect(ts.amountBg, x+40, doc.pdf.GetY(), 40, 10)
+	doc.cellFormat(40, 10, doc.ac.FormatMoneyDecimal(doc.Tax()), "0", 0, doc.mirrorAlign("L"), false)
 	doc.pdf.SetY(doc.pdf.GetY() + 10)
 
 	// Draw per-name breakdown in smaller font when named taxes exist.
 	if taxLines := doc.TaxLines(); taxLines != nil {
-		doc.pdf.SetFont(doc.Options.Font, "", SmallTextFontSize)
+		doc.pdf.SetFont(doc.Options.Font, "", st.SmallFontSize)
 		doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
 		for _, tl := range taxLines {
-			label := tl.Name
+			label, secondaryLabel := tl.Name, ""
 			if label == "" {
-				label = doc.Options.TextTotalTaxOther
+				label, secondaryLabel = doc.Options.TextTotalTaxOther, secondary.TextTotalTaxOther
 				if label == "" {
 					label = "Other"
 				}
 			}
-			doc.pdf.SetX(120)
-			doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-			doc.pdf.Rect(120, doc.pdf.GetY(), 80, 6, "F")
-			doc.pdf.CellFormat(38, 6, doc.encodeString(label), "0", 0, "R", false, 0, "")
-			doc.pdf.SetX(162)
-			doc.pdf.CellFormat(40, 6, doc.encodeString(doc.ac.FormatMoneyDecimal(tl.Amount)), "0", 0, "L", false, 0, "")
+			doc.pdf.SetX(doc.mirrorX(x, 38))
+			doc.fillRect(ts.amountBg, x, doc.pdf.GetY(), rightColumnWidth, 6)
+			doc.labelCell(38, 6, label, secondaryLabel, "R")
+			doc.pdf.SetX(doc.mirrorX(x+42, 40))
+			doc.cellFormat(40, 6, doc.ac.FormatMoneyDecimal(tl.Amount), "0", 0, doc.mirrorAlign("L"), false)
 			doc.pdf.SetY(doc.pdf.GetY() + 6)
 		}
-		doc.pdf.SetFont(doc.Options.Font, "", LargeTextFontSize)
+		doc.pdf.SetFont(doc.Options.Font, "", st.LargeFontSize)
 		doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
 	}
 
 	// Draw total with tax title
-	doc.pdf.SetX(120)
-	doc.pdf.SetFillColor(doc.Options.DarkBgColor[0], doc.Options.DarkBgColor[1], doc.Options.DarkBgColor[2])
-	doc.pdf.Rect(120, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(38, 10, doc.encodeString(doc.Options.TextTotalWithTax), "0", 0, "R", false, 0, "")
+	if ts.totalTextColor != nil {
+		doc.pdf.SetTextColor(ts.totalTextColor[0], ts.totalTextColor[1], ts.totalTextColor[2])
+	}
+	if ts.totalBold {
+		doc.pdf.SetFont(doc.Options.BoldFont, "B", st.LargeFontSize)
+	}
+	doc.pdf.SetX(doc.mirrorX(x, 38))
+	doc.fillRect(ts.totalLabelBg, x, doc.pdf.GetY(), 40, 10)
+	doc.labelCell(38, 10, doc.Options.TextTotalWithTax, secondary.TextTotalWithTax, "R")
 
 	// Draw total with tax amount
-	doc.pdf.SetX(162)
-	doc.pdf.SetFillColor(doc.Options.GreyBgColor[0], doc.Options.GreyBgColor[1], doc.Options.GreyBgColor[2])
-	doc.pdf.Rect(160, doc.pdf.GetY(), 40, 10, "F")
-	doc.pdf.CellFormat(
-		40,
-		10,
-		doc.encodeString(doc.ac.FormatMoneyDecimal(doc.TotalWithTax())),
+	doc.pdf.SetX(doc.mirrorX(x+42, 40))
+	doc.fillRect(ts.totalAmountBg, x+40, doc.pdf.GetY(), 40, 10)
+	doc.cellFormat(40, 10, doc.ac.FormatMoneyDecimal(doc.TotalWithTax()), "0", 0, doc.mirrorAlign("L"), false)
+	doc.pdf.SetY(doc.pdf.GetY() + 10)
+
+	doc.pdf.SetFont(doc.Options.Font, "", st.LargeFontSize)
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+}
+
+// appendAmountInWords to document, below the totals
+func (doc *Document) appendAmountInWords() {
+	if !doc.Options.AmountInWords {
+		return
+	}
+
+	words, err := doc.TotalInWords()
+	if err != nil {
+		// Already checked by Validate
+		return
+	}
+
+	doc.pdf.SetXY(doc.mirrorX(doc.rightColumnX(), rightColumnWidth), doc.pdf.GetY()+2)
+	doc.pdf.SetFont(doc.Options.Font, "", doc.style().SmallFontSize)
+	doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+	doc.multiCell(
+		rightColumnWidth,
+		3,
+		doc.label(doc.Options.TextAmountInWords, doc.secondary().TextAmountInWords)+" "+words,
 		"0",
-		0,
-		"L",
+		"R",
 		false,
-		0,
-		"",
 	)
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
 }
 
 // appendPaymentTerm to document
@@ -459,13 +637,13 @@ func (doc *Document) appendPaymentTerm() {
 	if len(doc.PaymentTerm) > 0 {
 		paymentTermString := fmt.Sprintf(
 			"%s: %s",
-			doc.encodeString(doc.Options.TextPaymentTermTitle),
-			doc.encodeString(doc.PaymentTerm),
+			doc.label(doc.Options.TextPaymentTermTitle, doc.secondary().TextPaymentTermTitle),
+			doc.PaymentTerm,
 		)
-		doc.pdf.SetY(doc.pdf.GetY() + 15)
+		doc.pdf.SetY(doc.pdf.GetY() + 5)
 
-		doc.pdf.SetX(120)
-		doc.pdf.SetFont(doc.Options.BoldFont, "B", 10)
-		doc.pdf.CellFormat(80, 4, doc.encodeString(paymentTermString), "0", 0, "R", false, 0, "")
+		doc.pdf.SetX(doc.mirrorX(doc.rightColumnX(), rightColumnWidth))
+		doc.pdf.SetFont(doc.Options.BoldFont, "B", doc.style().LargeFontSize)
+		doc.cellFormat(rightColumnWidth, 4, paymentTermString, "0", 0, doc.mirrorAlign("R"), false)
 	}
 }
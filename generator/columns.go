@@ -0,0 +1,287 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Keys of the built-in items table columns
+const (
+	ColumnName     string = "name"
+	ColumnUnitCost string = "unit_cost"
+	ColumnQuantity string = "quantity"
+	ColumnTotalHT  string = "total_ht"
+	ColumnDiscount string = "discount"
+	ColumnTax      string = "tax"
+	ColumnTotalTTC string = "total_ttc"
+)
+
+// Column alignments
+const (
+	AlignLeft   string = "L"
+	AlignCenter string = "C"
+	AlignRight  string = "R"
+)
+
+// Cell is the content of an items table cell
+type Cell struct {
+	Text string
+
+	// Detail is rendered below Text, in a smaller grey font
+	Detail string
+}
+
+// ColumnValueFunc returns the cell of item in a column
+type ColumnValueFunc func(doc *Document, item *Item) Cell
+
+// Column defines a column of the items table
+type Column struct {
+	// Key identifies the column. A column without Value renders the
+	// built-in column of the same key (see the Column* constants).
+	Key string `json:"key"`
+
+	// Title is the header label. Built-in columns default to their
+	// TextItems* option and SecondaryTitle to the secondary locale one.
+	Title          string `json:"title,omitempty"`
+	SecondaryTitle string `json:"secondary_title,omitempty"`
+
+	// Width is the column width in mm. Columns without Width share the width
+	// left by the others in proportion to their Flex (1 when zero).
+	Width float64 `json:"width,omitempty"`
+	Flex  float64 `json:"flex,omitempty"`
+
+	// Align is AlignLeft (default), AlignCenter or AlignRight
+	Align string `json:"align,omitempty"`
+
+	// Placeholder is rendered in cells without text
+	Placeholder string `json:"placeholder,omitempty"`
+
+	// HideWhenEmpty hides the column when no item has text in it
+	HideWhenEmpty bool `json:"hide_when_empty,omitempty"`
+
+	Value ColumnValueFunc `json:"-"`
+}
+
+// DefaultColumns returns the columns of the items table used when
+// Options.Columns is empty. The discount and tax columns are hidden when no
+// item has a discount or a tax.
+func DefaultColumns() []*Column {
+	return []*Column{
+		{Key: ColumnName},
+		{Key: ColumnUnitCost, Width: 23},
+		{Key: ColumnQuantity, Width: 10},
+		{Key: ColumnTotalHT, Width: 27},
+		{Key: ColumnDiscount, Width: 17, Placeholder: "--", HideWhenEmpty: true},
+		{Key: ColumnTax, Width: 18, Placeholder: "--", HideWhenEmpty: true},
+		{Key: ColumnTotalTTC, Width: 25},
+	}
+}
+
+// builtinColumn describes a built-in column
+type builtinColumn struct {
+	title func(opts *Options) string
+	value ColumnValueFunc
+}
+
+var builtinColumns = map[string]builtinColumn{
+	ColumnName: {
+		title: func(opts *Options) string { return opts.TextItemsNameTitle },
+		value: func(doc *Document, i *Item) Cell {
+			return Cell{Text: i.Name, Detail: i.Description}
+		},
+	},
+	ColumnUnitCost: {
+		title: func(opts *Options) string { return opts.TextItemsUnitCostTitle },
+		value: func(doc *Document, i *Item) Cell {
+			return Cell{Text: doc.ac.FormatMoneyDecimal(i._unitCost)}
+		},
+	},
+	ColumnQuantity: {
+		title: func(opts *Options) string { return opts.TextItemsQuantityTitle },
+		value: func(doc *Document, i *Item) Cell {
+			return Cell{Text: i._quantity.String()}
+		},
+	},
+	ColumnTotalHT: {
+		title: func(opts *Options) string { return opts.TextItemsTotalHTTitle },
+		value: func(doc *Document, i *Item) Cell {
+			return Cell{Text: doc.ac.FormatMoneyDecimal(i.TotalWithoutTaxAndWithoutDiscount())}
+		},
+	},
+	ColumnDiscount: {
+		title: func(opts *Options) string { return opts.TextItemsDiscountTitle },
+		value: discountCell,
+	},
+	ColumnTax: {
+		title: func(opts *Options) string { return opts.TextItemsTaxTitle },
+		value: taxCell,
+	},
+	ColumnTotalTTC: {
+		title: func(opts *Options) string { return opts.TextItemsTotalTTCTitle },
+		value: func(doc *Document, i *Item) Cell {
+			return Cell{Text: doc.ac.FormatMoneyDecimal(i.TotalWithTaxAndDiscount())}
+		},
+	},
+}
+
+func discountCell(doc *Document, i *Item) Cell {
+	if i.Discount == nil {
+		return Cell{}
+	}
+
+	discountType, discountAmount := i.Discount.getDiscount()
+	dCost := i.TotalWithoutTaxAndWithoutDiscount()
+
+	if discountType == DiscountTypePercent {
+		dAmount := dCost.Mul(discountAmount.Div(decimal.NewFromFloat(100)))
+		return Cell{
+			Text:   fmt.Sprintf("%s %s", discountAmount, "%"),
+			Detail: fmt.Sprintf("-%s", doc.ac.FormatMoneyDecimal(dAmount)),
+		}
+	}
+
+	dPerc := discountAmount.Mul(decimal.NewFromFloat(100)).Div(dCost)
+	return Cell{
+		Text:   fmt.Sprintf("%s %s", discountAmount, "€"),
+		Detail: fmt.Sprintf("-%s %%", dPerc.StringFixed(2)),
+	}
+}
+
+func taxCell(doc *Document, i *Item) Cell {
+	if i.Tax == nil {
+		return Cell{}
+	}
+
+	taxType, taxAmount := i.Tax.getTax()
+	dCost := i.TotalWithoutTaxAndWithDiscount()
+
+	if taxType == TaxTypePercent {
+		dAmount := dCost.Mul(taxAmount.Div(decimal.NewFromFloat(100)))
+		return Cell{
+			Text:   fmt.Sprintf("%s %s", taxAmount, "%"),
+			Detail: doc.ac.FormatMoneyDecimal(dAmount),
+		}
+	}
+
+	dPerc := taxAmount.Mul(decimal.NewFromFloat(100)).Div(dCost)
+	return Cell{
+		Text:   fmt.Sprintf("%s %s", doc.ac.Symbol, taxAmount),
+		Detail: fmt.Sprintf("%s %%", dPerc.StringFixed(2)),
+	}
+}
+
+// TableColumn is a visible items table column laid out on the page
+type TableColumn struct {
+	*Column
+
+	// X and W are the position and width of the column in mm
+	X, W float64
+
+	// Label and SecondaryLabel are the header labels of the column
+	Label          string
+	SecondaryLabel string
+
+	value ColumnValueFunc
+}
+
+// Cell returns the cell of item in the column, with the placeholder of the
+// column when it has no text
+func (col *TableColumn) Cell(doc *Document, item *Item) Cell {
+	cell := col.value(doc, item)
+	if len(cell.Text) == 0 {
+		cell.Text = col.Placeholder
+	}
+	return cell
+}
+
+// columns returns the columns of the items table
+func (doc *Document) columns() []*Column {
+	if len(doc.Options.Columns) > 0 {
+		return doc.Options.Columns
+	}
+	return DefaultColumns()
+}
+
+// validateColumns reports columns that have neither a Value nor a built-in key
+func (doc *Document) validateColumns(verr *ValidationError) {
+	for i, col := range doc.Options.Columns {
+		path := fmt.Sprintf("options.columns[%d]", i)
+		if col == nil {
+			verr.add(path, "required", "is required", nil)
+			continue
+		}
+		if _, ok := builtinColumns[col.Key]; !ok && col.Value == nil {
+			verr.add(path+".key", ErrorCodeInvalid, fmt.Sprintf("unknown column %q without value", col.Key), nil)
+		}
+	}
+}
+
+// layoutColumns resolves the visible columns and their position between left
+// and right
+func (doc *Document) layoutColumns(left, right float64) []*TableColumn {
+	secondary := doc.secondary()
+
+	var cols []*TableColumn
+	for _, col := range doc.columns() {
+		tc := &TableColumn{
+			Column:         col,
+			Label:          col.Title,
+			SecondaryLabel: col.SecondaryTitle,
+			value:          col.Value,
+		}
+		if builtin, ok := builtinColumns[col.Key]; ok {
+			if len(tc.Label) == 0 {
+				tc.Label = builtin.title(doc.Options)
+			}
+			if len(tc.SecondaryLabel) == 0 {
+				tc.SecondaryLabel = builtin.title(secondary)
+			}
+			if tc.value == nil {
+				tc.value = builtin.value
+			}
+		}
+
+		if col.HideWhenEmpty && doc.isColumnEmpty(tc.value) {
+			continue
+		}
+		cols = append(cols, tc)
+	}
+
+	fixed, flex := 0.0, 0.0
+	for _, col := range cols {
+		if col.Width > 0 {
+			fixed += col.Width
+		} else {
+			flex += col.flex()
+		}
+	}
+
+	x := left
+	for _, col := range cols {
+		col.X = x
+		col.W = col.Width
+		if col.W <= 0 && flex > 0 {
+			col.W = max(right-left-fixed, 0) * col.flex() / flex
+		}
+		x += col.W
+	}
+
+	return cols
+}
+
+func (col *TableColumn) flex() float64 {
+	if col.Flex > 0 {
+		return col.Flex
+	}
+	return 1
+}
+
+func (doc *Document) isColumnEmpty(value ColumnValueFunc) bool {
+	for _, item := range doc.Items {
+		if len(value(doc, item).Text) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -10,20 +10,60 @@ const (
 	// DeliveryNote define the "delievry note" document type
 	DeliveryNote string = "DELIVERY_NOTE"
 
+	// BilingualInline renders secondary labels on the same line as primary ones
+	BilingualInline string = "inline"
+
+	// BilingualStacked renders secondary labels below primary ones, in a smaller font
+	BilingualStacked string = "stacked"
+
+	// PageSizeA3 define the A3 page size (297 × 420 mm)
+	PageSizeA3 string = "A3"
+
+	// PageSizeA4 define the A4 page size (210 × 297 mm)
+	PageSizeA4 string = "A4"
+
+	// PageSizeA5 define the A5 page size (148 × 210 mm)
+	PageSizeA5 string = "A5"
+
+	// PageSizeLetter define the US Letter page size (8.5 × 11 in)
+	PageSizeLetter string = "Letter"
+
+	// PageSizeLegal define the US Legal page size (8.5 × 14 in)
+	PageSizeLegal string = "Legal"
+
+	// PageSizeCustom uses Options.PageWidth and Options.PageHeight
+	PageSizeCustom string = "Custom"
+
+	// OrientationPortrait define the portrait page orientation
+	OrientationPortrait string = "P"
+
+	// OrientationLandscape define the landscape page orientation
+	OrientationLandscape string = "L"
+
 	// BaseMargin define base margin used in documents
+	//
+	// Deprecated: use Style.Margin
 	BaseMargin float64 = 10
 
 	// BaseMarginTop define base margin top used in documents
+	//
+	// Deprecated: use Style.MarginTop
 	BaseMarginTop float64 = 20
 
 	// HeaderMarginTop define base header margin top used in documents
+	//
+	// Deprecated: use Style.HeaderMarginTop
 	HeaderMarginTop float64 = 5
 
 	// MaxPageHeight define the maximum height for a single page
+	//
+	// Deprecated: use Style.MaxPageHeight
 	MaxPageHeight float64 = 260
 )
 
 // Cols offsets
+//
+// Deprecated: use Options.Columns
 const (
 	// ItemColNameOffset ...
 	ItemColNameOffset float64 = 10
@@ -49,14 +89,22 @@ const (
 
 var (
 	// BaseTextFontSize define the base font size for text in document
+	//
+	// Deprecated: use Style.BaseFontSize, which defaults to it
 	BaseTextFontSize float64 = 8
 
 	// SmallTextFontSize define the small font size for text in document
+	//
+	// Deprecated: use Style.SmallFontSize, which defaults to it
 	SmallTextFontSize float64 = 7
 
 	// ExtraSmallTextFontSize define the extra small font size for text in document
+	//
+	// Deprecated: use Style.ExtraSmallFontSize, which defaults to it
 	ExtraSmallTextFontSize float64 = 6
 
 	// LargeTextFontSize define the large font size for text in document
+	//
+	// Deprecated: use Style.LargeFontSize, which defaults to it
 	LargeTextFontSize float64 = 10
 )
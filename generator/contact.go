@@ -37,9 +37,31 @@ func (a *Address) ToString() string {
 // Contact holds company or customer information
 type Contact struct {
 	Name    string   `json:"name,omitempty" validate:"required,min=1,max=256"`
-	Logo    []byte   `json:"logo,omitempty"`
 	Address *Address `json:"address,omitempty"`
 
+	// Logo is a PNG, JPEG, GIF or SVG image. SVG logos are drawn as vectors;
+	// gradients, clipping, text and stylesheets are not supported.
+	Logo       []byte      `json:"logo,omitempty"`
+	LogoLayout *LogoLayout `json:"logo_layout,omitempty"`
+
+	// VATID is the VAT identification number, e.g. FR12345678901
+	VATID string `json:"vat_id,omitempty"`
+
+	// RegistrationID is the company registration number in
+	// RegistrationScheme, e.g. SIREN, SIRET or HRB, used as its label
+	RegistrationID     string `json:"registration_id,omitempty"`
+	RegistrationScheme string `json:"registration_scheme,omitempty"`
+
+	// LegalForm (e.g. SAS, GmbH) and ShareCapital (e.g. 10 000 €) are
+	// printed as is
+	LegalForm    string `json:"legal_form,omitempty"`
+	ShareCapital string `json:"share_capital,omitempty"`
+
+	Email         string `json:"email,omitempty" validate:"omitempty,email"`
+	Phone         string `json:"phone,omitempty"`
+	Website       string `json:"website,omitempty"`
+	ContactPerson string `json:"contact_person,omitempty"`
+
 	// AddtionnalInfo lines appended after contact info; basic HTML (bold, italic) is supported
 	AddtionnalInfo []string `json:"additional_info,omitempty"`
 }
@@ -1,30 +1,18 @@
 package generator
 
-import (
-	"bytes"
-	b64 "encoding/base64"
-	"image"
+// contactWidth is the width of contact boxes, narrower for the company on
+// narrow pages
+const contactWidth float64 = 70
 
-	"codeberg.org/go-pdf/fpdf"
-)
-
-func (c *Contact) appendContactTODoc(x, y float64, fill bool, logoAlign string, doc *Document) float64 {
+// appendContactTODoc draws the contact block of c at x, y, width wide, its
+// logo being at most maxLogoWidth wide, and returns the Y below it
+func (c *Contact) appendContactTODoc(x, y, width float64, fill bool, maxLogoWidth float64, doc *Document) float64 {
+	x = doc.mirrorX(x, width)
 	doc.pdf.SetXY(x, y)
 
-	if c.Logo != nil {
-		fileName := b64.StdEncoding.EncodeToString([]byte(c.Name))
-		ioReader := bytes.NewReader(c.Logo)
-		_, format, _ := image.DecodeConfig(bytes.NewReader(c.Logo))
-
-		imageInfo := doc.pdf.RegisterImageOptionsReader(fileName, fpdf.ImageOptions{
-			ImageType: format,
-		}, ioReader)
-
-		if imageInfo != nil {
-			var imageOpt fpdf.ImageOptions
-			imageOpt.ImageType = format
-			doc.pdf.ImageOptions(fileName, doc.pdf.GetX(), y, 0, 30, false, imageOpt, 0, "")
-			doc.pdf.SetY(y + 30)
+	if c.Logo != nil && !c.logoLayout().InHeader {
+		if h := doc.appendContactLogo(c, x, y, width, maxLogoWidth); h > 0 {
+			doc.pdf.SetY(y + h)
 		}
 	}
 
@@ -35,10 +23,10 @@ func (c *Contact) appendContactTODoc(x, y float64, fill bool, logoAlign string,
 	}
 
 	doc.pdf.SetX(x)
-	doc.pdf.Rect(x, doc.pdf.GetY(), 70, 8, "F")
-	doc.pdf.SetFont(doc.Options.BoldFont, "B", 10)
-	doc.pdf.Cell(40, 8, doc.encodeString(c.Name))
-	doc.pdf.SetFont(doc.Options.Font, "", 10)
+	doc.pdf.Rect(x, doc.pdf.GetY(), width, 8, "F")
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", doc.style().LargeFontSize)
+	doc.cellFormat(width, 8, c.Name, "", 0, doc.mirrorAlign("L"), false)
+	doc.pdf.SetFont(doc.Options.Font, "", doc.style().LargeFontSize)
 
 	if c.Address != nil {
 		var addrRectHeight float64 = 17
@@ -48,32 +36,86 @@ func (c *Contact) appendContactTODoc(x, y float64, fill bool, logoAlign string,
 		if len(c.Address.Country) == 0 {
 			addrRectHeight -= 5
 		}
-		doc.pdf.Rect(x, doc.pdf.GetY()+9, 70, addrRectHeight, "F")
-		doc.pdf.SetFont(doc.Options.Font, "", 10)
+		doc.pdf.Rect(x, doc.pdf.GetY()+9, width, addrRectHeight, "F")
 		doc.pdf.SetXY(x, doc.pdf.GetY()+10)
-		doc.pdf.MultiCell(70, 5, doc.encodeString(c.Address.ToString()), "0", "L", false)
+		doc.multiCell(width, 5, c.Address.ToString(), "0", "L", false)
 	}
 
-	if c.AddtionnalInfo != nil {
+	if info := append(doc.contactDetails(c), c.AddtionnalInfo...); len(info) > 0 {
 		doc.pdf.SetXY(x, doc.pdf.GetY())
-		doc.pdf.SetFontSize(SmallTextFontSize)
+		doc.pdf.SetFontSize(doc.style().SmallFontSize)
 		doc.pdf.SetXY(x, doc.pdf.GetY()+2)
-		for _, line := range c.AddtionnalInfo {
+		for _, line := range info {
 			doc.pdf.SetXY(x, doc.pdf.GetY())
-			doc.pdf.MultiCell(70, 3, doc.encodeString(line), "0", "L", false)
+			doc.multiCell(width, 3, line, "0", "L", false)
 		}
 		doc.pdf.SetXY(x, doc.pdf.GetY())
-		doc.pdf.SetFontSize(BaseTextFontSize)
+		doc.pdf.SetFontSize(doc.style().BaseFontSize)
 	}
 
 	return doc.pdf.GetY()
 }
 
-func (c *Contact) appendCompanyContactToDoc(doc *Document) float64 {
+// contactDetails returns the typed details of c, one per line, in the order
+// they are drawn below the address: contact person, legal form and share
+// capital, registration and VAT numbers, phone, email and website
+func (doc *Document) contactDetails(c *Contact) []string {
+	opts, secondary := doc.Options, doc.secondary()
+	titled := func(title, secondaryTitle, value string) string {
+		return doc.label(title, secondaryTitle) + ": " + value
+	}
+
+	var lines []string
+	if len(c.ContactPerson) > 0 {
+		lines = append(lines, titled(opts.TextContactPersonTitle, secondary.TextContactPersonTitle, c.ContactPerson))
+	}
+
+	legal := c.LegalForm
+	if len(c.ShareCapital) > 0 {
+		capital := titled(opts.TextShareCapitalTitle, secondary.TextShareCapitalTitle, c.ShareCapital)
+		if len(legal) > 0 {
+			legal += ", " + capital
+		} else {
+			legal = capital
+		}
+	}
+	if len(legal) > 0 {
+		lines = append(lines, legal)
+	}
+
+	if len(c.RegistrationID) > 0 {
+		// The scheme names the number, in every language
+		title, secondaryTitle := c.RegistrationScheme, ""
+		if len(title) == 0 {
+			title, secondaryTitle = opts.TextRegistrationIDTitle, secondary.TextRegistrationIDTitle
+		}
+		lines = append(lines, titled(title, secondaryTitle, c.RegistrationID))
+	}
+	if len(c.VATID) > 0 {
+		lines = append(lines, titled(opts.TextVATIDTitle, secondary.TextVATIDTitle, c.VATID))
+	}
+	if len(c.Phone) > 0 {
+		lines = append(lines, titled(opts.TextPhoneTitle, secondary.TextPhoneTitle, c.Phone))
+	}
+	if len(c.Email) > 0 {
+		lines = append(lines, titled(opts.TextEmailTitle, secondary.TextEmailTitle, c.Email))
+	}
+	if len(c.Website) > 0 {
+		lines = append(lines, c.Website)
+	}
+	return lines
+}
+
+func (c *Contact) appendCompanyContactToDoc(doc *Document, fill bool) float64 {
 	x, y, _, _ := doc.pdf.GetMargins()
-	return c.appendContactTODoc(x, y, true, "L", doc)
+
+	// The block, and wide logos overflowing it, stop before the title column
+	maxWidth := doc.rightColumnX() - x - logoGap
+	return c.appendContactTODoc(x, y, min(contactWidth, maxWidth), fill, maxWidth, doc)
 }
 
-func (c *Contact) appendCustomerContactToDoc(doc *Document) float64 {
-	return c.appendContactTODoc(130, BaseMarginTop+25, true, "R", doc)
+func (c *Contact) appendCustomerContactToDoc(doc *Document, fill bool) float64 {
+	// Customer contact box is right aligned with the right column
+	x := doc.rightColumnX() + rightColumnWidth - contactWidth
+	return c.appendContactTODoc(x, doc.style().MarginTop+25, contactWidth, fill, contactWidth, doc)
 }
@@ -2,9 +2,11 @@ package generator
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
-	"github.com/creasty/defaults"
 	"codeberg.org/go-pdf/fpdf"
+	"github.com/creasty/defaults"
 	"github.com/go-playground/validator/v10"
 	"github.com/leekchan/accounting"
 )
@@ -16,6 +18,40 @@ type Document struct {
 	pdf *fpdf.Fpdf
 	ac  accounting.Accounting
 
+	// initialPdf is the PDF created by New, adopted by the first render
+	// unless the page settings changed since
+	initialPdf   *fpdf.Fpdf
+	initialPage  fpdf.InitType
+	pdfInitFuncs []PdfInitFunc
+
+	// cache, probe and tableColumns only live for the duration of a render
+	cache        *renderCache
+	probe        *fpdf.Fpdf
+	tableColumns []*TableColumn
+
+	// customTheme, set with SetTheme, takes precedence over Options.Theme
+	customTheme Theme
+
+	// letterheadStyle is the style with the letterhead margins of the last
+	// render of a document with a letterhead
+	letterheadStyle *Style
+
+	// appendixPages is the page count of the PDF appendices of the last
+	// render, merged when the PDF is written
+	appendixPages int
+
+	// pageTotal is the page count of the file during the second pass of
+	// documents whose header or footer Func needs it, zero otherwise
+	pageTotal int
+
+	// formFields are the form fields of the last render, added when the PDF
+	// is written
+	formFields []formField
+
+	// fonts holds the glyph coverage of the fonts of the last render of a
+	// document with fallback fonts
+	fonts *fontSet
+
 	Options      *Options      `json:"options,omitempty"`
 	Header       *HeaderFooter `json:"header,omitempty"`
 	Footer       *HeaderFooter `json:"footer,omitempty"`
@@ -27,16 +63,21 @@ type Document struct {
 	Notes        string        `json:"notes,omitempty"`
 	Company      *Contact      `json:"company,omitempty" validate:"required"`
 	Customer     *Contact      `json:"customer,omitempty" validate:"required"`
-	Items        []*Item       `json:"items,omitempty"`
+	ShipTo       *Contact      `json:"ship_to,omitempty"`
+	Items        []*Item       `json:"items,omitempty" validate:"dive"`
 	Date         string        `json:"date,omitempty"`
 	ValidityDate string        `json:"validity_date,omitempty"`
 	PaymentTerm  string        `json:"payment_term,omitempty"`
+	Payment      *Payment      `json:"payment,omitempty"`
 	DefaultTax   *Tax          `json:"default_tax,omitempty"`
 	Discount     *Discount     `json:"discount,omitempty"`
+	Watermark    *Watermark    `json:"watermark,omitempty"`
+	Appendices   []*Appendix   `json:"appendices,omitempty"`
 }
 
 // New return a new document with provided type and defaults
 func New(docType string, options *Options) (*Document, error) {
+	options.Style.setFontSizeDefaults()
 	_ = defaults.Set(options)
 
 	if docType != Invoice && docType != Quotation && docType != DeliveryNote {
@@ -48,8 +89,18 @@ func New(docType string, options *Options) (*Document, error) {
 		Type:    docType,
 	}
 
-	doc.pdf = fpdf.New("P", "mm", "A4", "")
-	registerDefaultFonts(doc.pdf)
+	if options.Fonts != nil {
+		verr := &ValidationError{}
+		doc.validateFonts(verr)
+		if err := verr.errOrNil(); err != nil {
+			return nil, err
+		}
+		options.Font, options.BoldFont = options.Fonts.Name, options.Fonts.Name
+	}
+
+	doc.pdf = doc.createPdf()
+	doc.initialPdf = doc.pdf
+	doc.initialPage = doc.pageInit()
 	// UTF-8 fonts (registered above) pass strings straight through; no cp1252
 	// translation is needed. Callers using a different font can override this.
 	doc.Options.UnicodeTranslateFunc = func(s string) string { return s }
@@ -64,36 +115,147 @@ func New(docType string, options *Options) (*Document, error) {
 	return doc, nil
 }
 
-// Validate document fields and prepare all monetary values
+// Validate document fields and prepare all monetary values.
+// It returns a *ValidationError listing every invalid field with its JSON
+// path (e.g. "items[3].unit_cost").
 func (d *Document) Validate() error {
+	verr := &ValidationError{}
+
 	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
 	if err := validate.Struct(d); err != nil {
-		return err
+		if err := verr.addValidatorErrors(err); err != nil {
+			return err
+		}
 	}
 
-	for _, item := range d.Items {
+	if d.DefaultTax != nil {
+		if err := d.DefaultTax.Prepare(); err != nil {
+			verr.merge("default_tax", err)
+		}
+	}
+
+	for idx, item := range d.Items {
+		path := fmt.Sprintf("items[%d]", idx)
+		if item == nil {
+			verr.add(path, "required", "is required", nil)
+			continue
+		}
+
+		usesDefaultTax := item.Tax == nil || item.Tax == d.DefaultTax
 		if item.Tax == nil {
 			item.Tax = d.DefaultTax
 		}
+
 		if err := item.Prepare(); err != nil {
-			return err
+			var itemErr *ValidationError
+			if !errors.As(err, &itemErr) {
+				verr.merge(path, err)
+				continue
+			}
+			for _, fe := range itemErr.Errors {
+				// Default tax errors are already reported under default_tax
+				if usesDefaultTax && (fe.Path == "tax" || strings.HasPrefix(fe.Path, "tax.")) {
+					continue
+				}
+				fe.Path = joinPath(path, fe.Path)
+				verr.Errors = append(verr.Errors, fe)
+			}
 		}
 	}
 
 	if d.Discount != nil {
 		if err := d.Discount.Prepare(); err != nil {
-			return err
+			verr.merge("discount", err)
 		}
 	}
 
-	return nil
+	if d.Options.PageSize == PageSizeCustom && (d.Options.PageWidth <= 0 || d.Options.PageHeight <= 0) {
+		verr.add("options.page_width", "required", "page width and height are required for custom page sizes", nil)
+	}
+
+	d.validateColumns(verr)
+	d.validateTheme(verr)
+	d.validateFonts(verr)
+	d.validateWatermark(verr)
+	d.validateLetterhead(verr)
+	d.validateAppendices(verr)
+	d.validatePayment(verr)
+
+	if d.Options.AmountInWords {
+		if _, err := d.currencyWords(); errors.Is(err, ErrUnsupportedWordsLanguage) {
+			verr.add("options.amount_in_words_language", ErrorCodeUnsupportedLanguage, err.Error(), err)
+		} else if err != nil {
+			verr.add("options.amount_in_words_currency", ErrorCodeUnknownCurrency, err.Error(), err)
+		}
+	}
+
+	return verr.errOrNil()
 }
 
-// Pdf returns the underlying *fpdf.Fpdf used to build the document
+// PdfInitFunc customizes a new *fpdf.Fpdf before the document is drawn into it
+type PdfInitFunc func(pdf *fpdf.Fpdf)
+
+// Pdf returns the underlying *fpdf.Fpdf of the last render (Build, Render or
+// Bytes). Before the first render it returns the PDF the first render will
+// draw into; changes made to it are not carried to later renders, register
+// them with OnPdfInit instead.
 func (doc *Document) Pdf() *fpdf.Fpdf {
 	return doc.pdf
 }
 
+// OnPdfInit registers fn to customize every PDF created to render the
+// document, e.g. to register additional fonts
+func (doc *Document) OnPdfInit(fn PdfInitFunc) *Document {
+	doc.pdfInitFuncs = append(doc.pdfInitFuncs, fn)
+	if doc.initialPdf != nil {
+		fn(doc.initialPdf)
+	}
+	return doc
+}
+
+// pageInit returns the fpdf page settings of the document
+func (doc *Document) pageInit() fpdf.InitType {
+	init := fpdf.InitType{
+		OrientationStr: doc.Options.PageOrientation,
+		UnitStr:        "mm",
+		SizeStr:        doc.Options.PageSize,
+	}
+	if doc.Options.PageSize == PageSizeCustom {
+		init.SizeStr = ""
+		init.Size = fpdf.SizeType{Wd: doc.Options.PageWidth, Ht: doc.Options.PageHeight}
+	}
+	return init
+}
+
+// createPdf returns a new PDF with the default fonts registered and the
+// OnPdfInit functions applied
+func (doc *Document) createPdf() *fpdf.Fpdf {
+	init := doc.pageInit()
+	pdf := fpdf.NewCustom(&init)
+	registerDefaultFonts(pdf)
+	doc.registerFonts(pdf)
+	for _, fn := range doc.pdfInitFuncs {
+		fn(pdf)
+	}
+	return pdf
+}
+
+// newPdf returns the PDF a render draws into. The first render adopts the
+// PDF created by New so that changes made through Pdf() before it are kept;
+// later renders start from a fresh PDF.
+func (doc *Document) newPdf() *fpdf.Fpdf {
+	if pdf := doc.initialPdf; pdf != nil {
+		doc.initialPdf = nil
+		if doc.initialPage == doc.pageInit() {
+			// Fonts may have been set after New
+			doc.registerFonts(pdf)
+			return pdf
+		}
+	}
+	return doc.createPdf()
+}
+
 // SetUnicodeTranslator sets a custom unicode translation function.
 // See https://pkg.go.dev/codeberg.org/go-pdf/fpdf#UnicodeTranslator
 func (doc *Document) SetUnicodeTranslator(fn UnicodeTranslateFunc) {
@@ -118,6 +280,18 @@ func (d *Document) SetFooter(footer *HeaderFooter) *Document {
 	return d
 }
 
+// SetWatermark sets the watermark stamped on the document pages
+func (d *Document) SetWatermark(watermark *Watermark) *Document {
+	d.Watermark = watermark
+	return d
+}
+
+// AddAppendix adds an appendix after the document pages
+func (d *Document) AddAppendix(appendix *Appendix) *Document {
+	d.Appendices = append(d.Appendices, appendix)
+	return d
+}
+
 // SetRef sets the document reference
 func (d *Document) SetRef(ref string) *Document {
 	d.Ref = ref
@@ -154,6 +328,20 @@ func (d *Document) SetCustomer(customer *Contact) *Document {
 	return d
 }
 
+// SetShipTo sets the ship-to contact, the delivery party when it is not the
+// customer. It is drawn below the customer, or instead of it on delivery
+// notes.
+func (d *Document) SetShipTo(shipTo *Contact) *Document {
+	d.ShipTo = shipTo
+	return d
+}
+
+// SetPayment sets the payment instructions
+func (d *Document) SetPayment(payment *Payment) *Document {
+	d.Payment = payment
+	return d
+}
+
 // AppendItem appends an item to the document
 func (d *Document) AppendItem(item *Item) *Document {
 	d.Items = append(d.Items, item)
@@ -184,55 +372,78 @@ func (d *Document) SetDiscount(discount *Discount) *Document {
 	return d
 }
 
+// encodeString prepares str for drawing on a single line: right to left
+// documents shape and reorder it, then UnicodeTranslateFunc converts it
 func (doc *Document) encodeString(str string) string {
+	if doc.isRTL() {
+		lines := strings.Split(shapeArabic(str), "\n")
+		for i, line := range lines {
+			lines[i] = visualOrder(line)
+		}
+		str = strings.Join(lines, "\n")
+	}
 	return doc.Options.UnicodeTranslateFunc(str)
 }
 
-func (d *Document) typeAsString() string {
+func (d *Document) typeAsString(opts *Options) string {
 	if d.Type == Invoice {
-		return d.Options.TextTypeInvoice
+		return opts.TextTypeInvoice
 	}
 	if d.Type == Quotation {
-		return d.Options.TextTypeQuotation
+		return opts.TextTypeQuotation
 	}
-	return d.Options.TextTypeDeliveryNote
+	return opts.TextTypeDeliveryNote
 }
 
-func (d *Document) fakePdfDoc() *Document {
-	optsCopy := *d.Options
-	fakeDoc, err := New(d.Type, &optsCopy)
-	if err != nil {
-		// Should never panic, since we already called New() with the same args.
-		panic(err)
+// maxProbePages is the page count past which a probe PDF is replaced, so
+// that a probe reused for many documents does not grow forever
+const maxProbePages int = 50
+
+// probePdf returns the PDF page break probes draw into. It is created once per
+// render, or shared by the documents of a batch worker that use the default
+// fonts only, instead of re-parsing the fonts for every probe.
+func (d *Document) probePdf() *fpdf.Fpdf {
+	shared := d.cache != nil && len(d.pdfInitFuncs) == 0 && d.Options.Fonts == nil && len(d.Options.FallbackFonts) == 0
+
+	probe := d.probe
+	if shared {
+		probe = d.cache.probe
+	}
+	if probe == nil || probe.Err() || probe.PageCount() >= maxProbePages {
+		probe = d.createPdf()
 	}
 
+	if shared {
+		d.cache.probe = probe
+	} else {
+		d.probe = probe
+	}
+	return probe
+}
+
+func (d *Document) fakePdfDoc() *Document {
 	// Copy data fields so conditional rendering logic (e.g. discount block in
 	// appendTotal, PaymentTerm guard in appendPaymentTerm) behaves identically
 	// in the probe and in the real render.
-	fakeDoc.Header = d.Header
-	fakeDoc.Footer = d.Footer
-	fakeDoc.Ref = d.Ref
-	fakeDoc.Version = d.Version
-	fakeDoc.ClientRef = d.ClientRef
-	fakeDoc.Description = d.Description
-	fakeDoc.Notes = d.Notes
-	fakeDoc.Company = d.Company
-	fakeDoc.Customer = d.Customer
-	fakeDoc.Items = d.Items
-	fakeDoc.Date = d.Date
-	fakeDoc.ValidityDate = d.ValidityDate
-	fakeDoc.PaymentTerm = d.PaymentTerm
-	fakeDoc.DefaultTax = d.DefaultTax
-	fakeDoc.Discount = d.Discount
-
-	pageCount := d.pdf.PageCount()
-	for i := 0; i < pageCount; i++ {
-		fakeDoc.pdf.AddPage()
+	fakeDoc := *d
+	fakeDoc.initialPdf = nil
+	fakeDoc.pdf = d.probePdf()
+
+	// Mirror the page geometry, font and position of the real document on a
+	// blank page of the probe
+	left, top, right, _ := d.pdf.GetMargins()
+	autoPageBreak, bottom := d.pdf.GetAutoPageBreak()
+	fakeDoc.pdf.SetMargins(left, top, right)
+	fakeDoc.pdf.SetAutoPageBreak(autoPageBreak, bottom)
+	fakeDoc.pdf.AddPage()
+
+	if family := d.pdf.GetFontFamily(); len(family) > 0 {
+		fontSize, _ := d.pdf.GetFontSize()
+		fakeDoc.pdf.SetFont(family, d.pdf.GetFontStyle(), fontSize)
 	}
-	fakeDoc.pdf.SetPage(d.pdf.PageNo())
 	fakeDoc.pdf.SetXY(d.pdf.GetXY())
 
-	return fakeDoc
+	return &fakeDoc
 }
 
 type pageTxnFn func(*Document)
@@ -242,15 +453,29 @@ type pageTxnFn func(*Document)
 // and onBreak (if provided) runs before the real render — use it to redraw
 // table headers or reset font/position state.
 func (d *Document) pageTxn(cb pageTxnFn, onBreak ...pageTxnFn) {
+	var after pageTxnFn
+	if len(onBreak) > 0 {
+		after = onBreak[0]
+	}
+	d.reservedPageTxn(cb, 0, nil, after)
+}
+
+// reservedPageTxn is pageTxn keeping reserve mm free above the max page
+// height. On a page break, before (if not nil) runs on the current page,
+// in the reserved space, and after on the new page.
+func (d *Document) reservedPageTxn(cb pageTxnFn, reserve float64, before, after pageTxnFn) {
 	fdoc := d.fakePdfDoc()
 
 	currentPage := fdoc.pdf.PageNo()
 	cb(fdoc)
 
-	if fdoc.pdf.PageNo() > currentPage || fdoc.pdf.GetY() > MaxPageHeight {
+	if fdoc.pdf.PageNo() > currentPage || fdoc.pdf.GetY() > d.maxPageHeight()-reserve {
+		if before != nil {
+			before(d)
+		}
 		d.pdf.AddPage()
-		if len(onBreak) > 0 && onBreak[0] != nil {
-			onBreak[0](d)
+		if after != nil {
+			after(d)
 		}
 	}
 
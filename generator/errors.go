@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// Error codes of FieldError, besides the validator tags ("required", "max",
+// "oneof", ...)
+const (
+	ErrorCodeInvalidDecimal      string = "invalid_decimal"
+	ErrorCodeInvalidTax          string = "invalid_tax"
+	ErrorCodeInvalidDiscount     string = "invalid_discount"
+	ErrorCodeUnsupportedLanguage string = "unsupported_language"
+	ErrorCodeUnknownCurrency     string = "unknown_currency"
+	ErrorCodeInvalid             string = "invalid"
+)
+
+// FieldError describes one invalid document field
+type FieldError struct {
+	// Path is the JSON path of the field, e.g. "items[3].unit_cost"
+	Path string `json:"path"`
+
+	// Code is a machine readable error code, see the ErrorCode constants
+	Code string `json:"code"`
+
+	// Message is a human readable description of the problem
+	Message string `json:"message"`
+
+	// Err is the underlying error, if any (e.g. ErrInvalidTax)
+	Err error `json:"-"`
+}
+
+func (e *FieldError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem found while validating a document,
+// an item, a tax or a discount
+type ValidationError struct {
+	Errors []*FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid document: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors so that errors.Is and errors.As match
+// their underlying errors
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+func (e *ValidationError) add(path, code, message string, err error) {
+	e.Errors = append(e.Errors, &FieldError{Path: path, Code: code, Message: message, Err: err})
+}
+
+// merge appends the errors of err with their path prefixed by prefix
+func (e *ValidationError) merge(prefix string, err error) {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		e.add(prefix, ErrorCodeInvalid, err.Error(), err)
+		return
+	}
+
+	for _, fe := range verr.Errors {
+		fe.Path = joinPath(prefix, fe.Path)
+		e.Errors = append(e.Errors, fe)
+	}
+}
+
+// parseDecimal parses value, recording an ErrorCodeInvalidDecimal error at
+// path on failure
+func (e *ValidationError) parseDecimal(path, value string) decimal.Decimal {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		e.add(path, ErrorCodeInvalidDecimal, fmt.Sprintf("%q is not a decimal number", value), err)
+	}
+	return d
+}
+
+// errOrNil returns e as an error, or a nil error when e holds no errors
+func (e *ValidationError) errOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func joinPath(prefix, path string) string {
+	if len(prefix) == 0 {
+		return path
+	}
+	if len(path) == 0 {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+// jsonFieldName makes validator report JSON field names
+func jsonFieldName(fld reflect.StructField) string {
+	name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if len(name) == 0 {
+		return fld.Name
+	}
+	return name
+}
+
+// addValidatorErrors converts validator errors to field errors
+func (e *ValidationError) addValidatorErrors(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	for _, fe := range verrs {
+		// Strip the root struct name, e.g. "Document.items[0].name"
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		e.add(path, fe.Tag(), validatorMessage(fe), nil)
+	}
+
+	return nil
+}
+
+func validatorMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must contain at least %s elements", fe.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must contain at most %s elements", fe.Param())
+	case "len":
+		return fmt.Sprintf("must contain %s elements", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,367 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+
+	"codeberg.org/go-pdf/fpdf"
+	"golang.org/x/image/font/sfnt"
+)
+
+// fontSet is the glyph coverage of the fonts of a document with fallback
+// fonts
+type fontSet struct {
+	// faces holds the parsed regular TTF of each known family, by lower case
+	// family name as fpdf reports it
+	faces map[string]*sfnt.Font
+
+	// fallbacks are the Options.FallbackFonts family names, in order
+	fallbacks []string
+
+	// italic holds the fallback families with an italic style
+	italic map[string]bool
+
+	buf sfnt.Buffer
+}
+
+// newFontSet parses the fonts of doc, nil when it has no fallback fonts
+func (doc *Document) newFontSet() *fontSet {
+	if len(doc.Options.FallbackFonts) == 0 {
+		return nil
+	}
+
+	fs := &fontSet{faces: map[string]*sfnt.Font{}, italic: map[string]bool{}}
+	if f, err := sfnt.Parse(robotoRegular); err == nil {
+		fs.faces["roboto"] = f
+	}
+	if fonts := doc.Options.Fonts; fonts != nil {
+		if f, err := sfnt.Parse(fonts.Regular); err == nil {
+			fs.faces[strings.ToLower(fonts.Name)] = f
+		}
+	}
+	for _, family := range doc.Options.FallbackFonts {
+		if family == nil {
+			continue
+		}
+		name := strings.ToLower(family.Name)
+		if f, err := sfnt.Parse(family.Regular); err == nil {
+			fs.faces[name] = f
+		}
+		fs.fallbacks = append(fs.fallbacks, name)
+		fs.italic[name] = len(family.Italic) > 0
+	}
+	return fs
+}
+
+// hasGlyph reports whether family has a glyph for r. Families registered
+// outside of the document (OnPdfInit) are assumed to have every glyph.
+func (fs *fontSet) hasGlyph(family string, r rune) bool {
+	f, ok := fs.faces[family]
+	if !ok {
+		return true
+	}
+	idx, err := f.GlyphIndex(&fs.buf, r)
+	return err == nil && idx != 0
+}
+
+// family returns the first family of family and the fallbacks with a glyph
+// for r, family itself when none has
+func (fs *fontSet) family(family string, r rune) string {
+	if fs.hasGlyph(family, r) {
+		return family
+	}
+	for _, fallback := range fs.fallbacks {
+		if fs.hasGlyph(fallback, r) {
+			return fallback
+		}
+	}
+	return family
+}
+
+// fontRun is a part of a text drawn in a single font family
+type fontRun struct {
+	family string
+	text   string
+}
+
+// fontRuns splits text into runs of the current font family and of the
+// fallback fonts. Spaces stay in the run they follow.
+func (doc *Document) fontRuns(text string) []fontRun {
+	current := doc.pdf.GetFontFamily()
+	if doc.fonts == nil {
+		return []fontRun{{family: current, text: text}}
+	}
+
+	var runs []fontRun
+	var b strings.Builder
+	family := ""
+	for _, r := range text {
+		next := family
+		if !unicode.IsSpace(r) || len(family) == 0 {
+			next = doc.fonts.family(current, r)
+		}
+		if next != family && b.Len() > 0 {
+			runs = append(runs, fontRun{family: family, text: b.String()})
+			b.Reset()
+		}
+		family = next
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		runs = append(runs, fontRun{family: family, text: b.String()})
+	}
+	return runs
+}
+
+// setRunFont selects the fallback family in style (e.g. "BU") at the
+// current size, and reports whether the font changed. Fallback families
+// without italic style draw italic text upright.
+func (doc *Document) setRunFont(family, style string) bool {
+	if family == doc.pdf.GetFontFamily() {
+		return false
+	}
+	if !doc.fonts.italic[family] {
+		style = strings.ReplaceAll(style, "I", "")
+	}
+	doc.pdf.SetFont(family, style, 0)
+	return true
+}
+
+// fontStyle returns style without italic when family is registered without
+// an italic style, like the embedded Roboto, so that italic text is drawn
+// upright instead of failing with an undefined font
+func (doc *Document) fontStyle(family, style string) string {
+	key := strings.ReplaceAll(style, "U", "")
+	if !strings.Contains(key, "I") || doc.pdf.GetFontDesc(family, key) != (fpdf.FontDescType{}) ||
+		doc.pdf.GetFontDesc(family, "") == (fpdf.FontDescType{}) {
+		return style
+	}
+	return strings.ReplaceAll(style, "I", "")
+}
+
+// textWidth returns the width of the encoded text, each font run measured
+// with its own font
+func (doc *Document) textWidth(text string) float64 {
+	family, style := doc.pdf.GetFontFamily(), doc.pdf.GetFontStyle()
+	width := 0.0
+	for _, run := range doc.fontRuns(text) {
+		switched := doc.setRunFont(run.family, style)
+		width += doc.pdf.GetStringWidth(run.text)
+		if switched {
+			doc.pdf.SetFont(family, style, 0)
+		}
+	}
+	return width
+}
+
+// wrapLine wraps the encoded text of a single paragraph into lines of at
+// most w, breaking at spaces when it can
+func (doc *Document) wrapLine(text string, w float64) []string {
+	if doc.fonts == nil {
+		return doc.pdf.SplitText(text, w)
+	}
+
+	maxWidth := w - 2*doc.pdf.GetCellMargin()
+	runes := []rune(text)
+	var lines []string
+	start, space := 0, -1
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == ' ' {
+			space = i
+		}
+		if i == start || doc.textWidth(string(runes[start:i+1])) <= maxWidth {
+			continue
+		}
+		if space > start {
+			lines = append(lines, string(runes[start:space]))
+			start = space + 1
+		} else {
+			lines = append(lines, string(runes[start:i]))
+			start = i
+		}
+		space = -1
+	}
+	if start < len(runes) {
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+// cellFormat draws str like fpdf CellFormat, without link
+func (doc *Document) cellFormat(w, h float64, str, borderStr string, ln int, alignStr string, fill bool) {
+	doc.drawCell(w, h, doc.encodeString(str), borderStr, ln, alignStr, fill)
+}
+
+// drawCell draws the encoded text like fpdf CellFormat. Text with several
+// font runs is drawn run by run, aligned as a whole in the cell.
+func (doc *Document) drawCell(w, h float64, text, borderStr string, ln int, alignStr string, fill bool) {
+	runs := doc.fontRuns(text)
+	if len(runs) <= 1 && (len(runs) == 0 || runs[0].family == doc.pdf.GetFontFamily()) {
+		doc.pdf.CellFormat(w, h, text, borderStr, ln, alignStr, fill, 0, "")
+		return
+	}
+
+	// Border and fill first: a page break moves the cell to the next page
+	doc.pdf.CellFormat(w, h, "", borderStr, 0, "", fill, 0, "")
+	x, y := doc.pdf.GetXY()
+	x -= w
+
+	width := doc.textWidth(text)
+	margin := doc.pdf.GetCellMargin()
+	tx := x + margin
+	switch {
+	case strings.Contains(alignStr, "R"):
+		tx = x + w - margin - width
+	case strings.Contains(alignStr, "C"):
+		tx = x + (w-width)/2
+	}
+	vAlign := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("LCR", r) {
+			return -1
+		}
+		return r
+	}, alignStr)
+
+	family, style := doc.pdf.GetFontFamily(), doc.pdf.GetFontStyle()
+	doc.pdf.SetCellMargin(0)
+	for _, run := range runs {
+		switched := doc.setRunFont(run.family, style)
+		runWidth := doc.pdf.GetStringWidth(run.text)
+		doc.pdf.SetXY(tx, y)
+		doc.pdf.CellFormat(runWidth, h, run.text, "", 0, "L"+vAlign, false, 0, "")
+		tx += runWidth
+		if switched {
+			doc.pdf.SetFont(family, style, 0)
+		}
+	}
+	doc.pdf.SetCellMargin(margin)
+
+	switch ln {
+	case 1:
+		left, _, _, _ := doc.pdf.GetMargins()
+		doc.pdf.SetXY(left, y+h)
+	case 2:
+		doc.pdf.SetXY(x, y+h)
+	default:
+		doc.pdf.SetXY(x+w, y)
+	}
+}
+
+// writeHTMLRuns writes the encoded basic HTML str like fpdf HTMLBasicType
+// Write, drawing its texts in font runs
+func (doc *Document) writeHTMLRuns(lineHt float64, str string) {
+	family, baseStyle := doc.pdf.GetFontFamily(), doc.pdf.GetFontStyle()
+	r, g, b := doc.pdf.GetTextColor()
+	bold, italic, underline := 0, 0, 0
+	style := func() string {
+		s := ""
+		if bold > 0 {
+			s += "B"
+		}
+		if italic > 0 {
+			s += "I"
+		}
+		if underline > 0 {
+			s += "U"
+		}
+		return s
+	}
+	setStyle := func() {
+		doc.pdf.SetFont(family, doc.fontStyle(family, style()), 0)
+	}
+
+	align, href := "L", ""
+	for _, el := range fpdf.HTMLBasicTokenize(str) {
+		switch el.Cat {
+		case 'T':
+			if len(href) > 0 {
+				// Same link style as HTMLBasicNew
+				doc.pdf.SetTextColor(0, 0, 128)
+				underline++
+				setStyle()
+				doc.writeRuns(lineHt, el.Str, align, href)
+				underline--
+				setStyle()
+				doc.pdf.SetTextColor(r, g, b)
+				href = ""
+			} else {
+				doc.writeRuns(lineHt, el.Str, align, "")
+			}
+		case 'O':
+			switch el.Str {
+			case "b":
+				bold++
+				setStyle()
+			case "i":
+				italic++
+				setStyle()
+			case "u":
+				underline++
+				setStyle()
+			case "br":
+				doc.pdf.Ln(lineHt)
+			case "center", "right", "left":
+				doc.pdf.Ln(lineHt)
+				align = strings.ToUpper(el.Str[:1])
+			case "a":
+				href = el.Attr["href"]
+			}
+		case 'C':
+			switch el.Str {
+			case "b":
+				bold--
+				setStyle()
+			case "i":
+				italic--
+				setStyle()
+			case "u":
+				underline--
+				setStyle()
+			case "center", "right", "left":
+				doc.pdf.Ln(lineHt)
+				align = "L"
+			}
+		}
+	}
+	if style() != baseStyle {
+		doc.pdf.SetFont(family, baseStyle, 0)
+	}
+}
+
+// writeRuns writes the encoded text from the current position like fpdf
+// Write, or WriteAligned for "C" and "R" alignments
+func (doc *Document) writeRuns(lineHt float64, text, align, link string) {
+	if align != "C" && align != "R" {
+		doc.writeFontRuns(lineHt, text, link)
+		return
+	}
+
+	left, _, right, _ := doc.pdf.GetMargins()
+	pageWidth, _ := doc.pdf.GetPageSize()
+	width := pageWidth - left - right
+	for _, line := range doc.wrapLine(text, width) {
+		offset := (width - doc.textWidth(line)) / 2
+		if align == "R" {
+			offset = width - doc.textWidth(line) - 2.01*doc.pdf.GetCellMargin()
+		}
+		doc.pdf.SetLeftMargin(left + offset)
+		doc.writeFontRuns(lineHt, line, link)
+		doc.pdf.SetLeftMargin(left)
+	}
+}
+
+// writeFontRuns writes each font run of text with fpdf Write
+func (doc *Document) writeFontRuns(lineHt float64, text, link string) {
+	family, style := doc.pdf.GetFontFamily(), doc.pdf.GetFontStyle()
+	for _, run := range doc.fontRuns(text) {
+		switched := doc.setRunFont(run.family, style)
+		if len(link) > 0 {
+			doc.pdf.WriteLinkString(lineHt, run.text, link)
+		} else {
+			doc.pdf.Write(lineHt, run.text)
+		}
+		if switched {
+			doc.pdf.SetFont(family, style, 0)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package generator
 
 import (
 	_ "embed"
+	"fmt"
 
 	"codeberg.org/go-pdf/fpdf"
+	"golang.org/x/image/font/sfnt"
 )
 
 //go:embed fonts/Roboto-Regular.ttf
@@ -18,3 +20,97 @@ func registerDefaultFonts(pdf *fpdf.Fpdf) {
 	pdf.AddUTF8FontFromBytes("Roboto", "", robotoRegular)
 	pdf.AddUTF8FontFromBytes("Roboto", "B", robotoBold)
 }
+
+// FontFamily is a TrueType font family, of Options.Fonts or
+// Options.FallbackFonts
+type FontFamily struct {
+	// Name is the family name the fonts are registered under
+	Name string `json:"name"`
+
+	// Regular, Bold, Italic and BoldItalic hold the TTF files. Options.Fonts
+	// requires all four; fallback fonts only require Regular, Bold defaults
+	// to it and fallback families without Italic draw italic text upright.
+	Regular    []byte `json:"regular"`
+	Bold       []byte `json:"bold,omitempty"`
+	Italic     []byte `json:"italic,omitempty"`
+	BoldItalic []byte `json:"bold_italic,omitempty"`
+}
+
+// register adds the styles of family to pdf. Families already registered
+// are skipped by fpdf.
+func (family *FontFamily) register(pdf *fpdf.Fpdf) {
+	bold := family.Bold
+	if len(bold) == 0 {
+		bold = family.Regular
+	}
+	pdf.AddUTF8FontFromBytes(family.Name, "", family.Regular)
+	pdf.AddUTF8FontFromBytes(family.Name, "B", bold)
+
+	if len(family.Italic) == 0 {
+		return
+	}
+	boldItalic := family.BoldItalic
+	if len(boldItalic) == 0 {
+		boldItalic = family.Italic
+	}
+	pdf.AddUTF8FontFromBytes(family.Name, "I", family.Italic)
+	pdf.AddUTF8FontFromBytes(family.Name, "BI", boldItalic)
+}
+
+// registerFonts registers the Options.Fonts and Options.FallbackFonts
+// families on pdf
+func (doc *Document) registerFonts(pdf *fpdf.Fpdf) {
+	if doc.Options.Fonts != nil {
+		doc.Options.Fonts.register(pdf)
+	}
+	for _, family := range doc.Options.FallbackFonts {
+		if family != nil {
+			family.register(pdf)
+		}
+	}
+}
+
+// validateFonts reports missing Options.Fonts styles and font files that are
+// not TrueType fonts
+func (doc *Document) validateFonts(verr *ValidationError) {
+	if doc.Options.Fonts != nil {
+		validateFontFamily(verr, "options.fonts", doc.Options.Fonts, true)
+	}
+	for i, family := range doc.Options.FallbackFonts {
+		path := fmt.Sprintf("options.fallback_fonts[%d]", i)
+		if family == nil {
+			verr.add(path, "required", "is required", nil)
+			continue
+		}
+		validateFontFamily(verr, path, family, false)
+	}
+}
+
+// validateFontFamily validates family, with all its styles when allStyles is
+// set and only the regular one otherwise
+func validateFontFamily(verr *ValidationError, path string, family *FontFamily, allStyles bool) {
+	if len(family.Name) == 0 {
+		verr.add(path+".name", "required", "is required", nil)
+	}
+
+	styles := []struct {
+		name string
+		ttf  []byte
+	}{
+		{"regular", family.Regular},
+		{"bold", family.Bold},
+		{"italic", family.Italic},
+		{"bold_italic", family.BoldItalic},
+	}
+	for i, style := range styles {
+		if len(style.ttf) == 0 {
+			if i == 0 || allStyles {
+				verr.add(path+"."+style.name, "required", fmt.Sprintf("the %s style is required", style.name), nil)
+			}
+			continue
+		}
+		if _, err := sfnt.Parse(style.ttf); err != nil {
+			verr.add(path+"."+style.name, ErrorCodeInvalid, "is not a TrueType font", err)
+		}
+	}
+}
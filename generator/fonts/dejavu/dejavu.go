@@ -0,0 +1,52 @@
+// Package dejavu embeds the DejaVu Sans Condensed fonts. Unlike the default
+// Roboto font they have Arabic (with presentation forms) and Hebrew glyphs,
+// for right to left documents, and an italic style:
+//
+//	doc, _ := generator.New(generator.Invoice, &generator.Options{
+//		Direction: generator.DirectionRTL,
+//		Fonts: &generator.FontFamily{
+//			Name:       dejavu.Family,
+//			Regular:    dejavu.Regular,
+//			Bold:       dejavu.Bold,
+//			Italic:     dejavu.Italic,
+//			BoldItalic: dejavu.BoldItalic,
+//		},
+//	})
+package dejavu
+
+import (
+	_ "embed"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+// Family is the font family name Register adds
+const Family = "DejaVu"
+
+// Regular is the DejaVu Sans Condensed TTF
+//
+//go:embed DejaVuSansCondensed.ttf
+var Regular []byte
+
+// Bold is the DejaVu Sans Condensed Bold TTF
+//
+//go:embed DejaVuSansCondensed-Bold.ttf
+var Bold []byte
+
+// Italic is the DejaVu Sans Condensed Oblique TTF
+//
+//go:embed DejaVuSansCondensed-Oblique.ttf
+var Italic []byte
+
+// BoldItalic is the DejaVu Sans Condensed Bold Oblique TTF
+//
+//go:embed DejaVuSansCondensed-BoldOblique.ttf
+var BoldItalic []byte
+
+// Register adds the four styles of Family to pdf
+func Register(pdf *fpdf.Fpdf) {
+	pdf.AddUTF8FontFromBytes(Family, "", Regular)
+	pdf.AddUTF8FontFromBytes(Family, "B", Bold)
+	pdf.AddUTF8FontFromBytes(Family, "I", Italic)
+	pdf.AddUTF8FontFromBytes(Family, "BI", BoldItalic)
+}
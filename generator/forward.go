@@ -0,0 +1,60 @@
+package generator
+
+import "github.com/shopspring/decimal"
+
+// forwardRowHeight is the height of the carried and brought forward rows
+const forwardRowHeight float64 = 6
+
+// forwardTotals are the running totals of the items drawn so far, in the
+// total columns of the items table
+type forwardTotals struct {
+	count               int
+	withoutTax, withTax decimal.Decimal
+}
+
+// add adds item to the running totals
+func (ft *forwardTotals) add(item *Item) {
+	ft.count++
+	ft.withoutTax = ft.withoutTax.Add(item.TotalWithoutTaxAndWithoutDiscount())
+	ft.withTax = ft.withTax.Add(item.TotalWithTaxAndDiscount())
+}
+
+// appendForwardRow draws a carried or brought forward row of the items
+// table at the current Y, its amounts under the built-in total columns, or
+// the total with tax under the last column when there are none
+func (doc *Document) appendForwardRow(label, secondaryLabel string, ft *forwardTotals) {
+	st := doc.style()
+	left, right := doc.tableBounds()
+	y := doc.pdf.GetY()
+
+	amounts := map[string]decimal.Decimal{ColumnTotalHT: ft.withoutTax, ColumnTotalTTC: ft.withTax}
+	var cols []*TableColumn
+	for _, col := range doc.tableColumns {
+		if _, ok := amounts[col.Key]; ok && col.Value == nil {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		last := doc.tableColumns[len(doc.tableColumns)-1]
+		cols = []*TableColumn{{Column: &Column{Key: ColumnTotalTTC, Align: last.Align}, X: last.X, W: last.W}}
+	}
+
+	doc.fillRect(doc.Options.GreyBgColor, left, y, right-left, forwardRowHeight)
+
+	labelW := max(cols[0].X-left, 0)
+	doc.pdf.SetXY(doc.mirrorX(left, labelW), y)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", st.BaseFontSize)
+	doc.labelCell(labelW, forwardRowHeight, label, secondaryLabel, "L")
+
+	doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+	for _, col := range cols {
+		align := col.Align
+		if len(align) == 0 {
+			align = AlignLeft
+		}
+		doc.pdf.SetXY(doc.mirrorX(col.X, col.W), y)
+		doc.cellFormat(col.W, forwardRowHeight, doc.ac.FormatMoneyDecimal(amounts[col.Key]), "0", 0, doc.mirrorAlign(align), false)
+	}
+
+	doc.pdf.SetXY(st.Margin, y+forwardRowHeight)
+}
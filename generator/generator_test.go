@@ -1,9 +1,22 @@
 package generator
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+
+	"codeberg.org/go-pdf/fpdf"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/angelodlfrtr/go-invoice-generator/generator/fonts/dejavu"
 )
 
 func TestNewWithNamedTaxes(t *testing.T) {
@@ -249,3 +262,1526 @@ func TestNew(t *testing.T) {
 		t.Errorf("%v", err.Error())
 	}
 }
+
+func TestNewBilingual(t *testing.T) {
+	for _, layout := range []string{BilingualInline, BilingualStacked} {
+		t.Run(layout, func(t *testing.T) {
+			doc, err := New(Invoice, &Options{
+				TextTypeInvoice:        "FACTURE",
+				TextRefTitle:           "Réf.",
+				TextDateTitle:          "Date",
+				TextPaymentTermTitle:   "Échéance",
+				TextItemsNameTitle:     "Désignation",
+				TextItemsUnitCostTitle: "Prix unitaire",
+				TextItemsQuantityTitle: "Qté",
+				TextItemsTotalHTTitle:  "Total HT",
+				TextItemsTaxTitle:      "TVA",
+				TextItemsDiscountTitle: "Remise",
+				TextItemsTotalTTCTitle: "Total TTC",
+				TextTotalTotal:         "Total",
+				TextTotalDiscounted:    "Total remisé",
+				TextTotalTax:           "TVA",
+				TextTotalWithTax:       "Total TTC",
+				BilingualLayout:        layout,
+				Secondary:              &Options{},
+			})
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+
+			if got := doc.label(doc.Options.TextTypeInvoice, doc.secondary().TextTypeInvoice); got != "FACTURE / INVOICE" {
+				t.Fatalf("label = %q, want %q", got, "FACTURE / INVOICE")
+			}
+			if got := doc.label(doc.Options.TextDateTitle, doc.secondary().TextDateTitle); got != "Date" {
+				t.Fatalf("identical labels must not be repeated, got %q", got)
+			}
+
+			doc.SetRef("FA-2024-001")
+			doc.SetDate("01/05/2024")
+			doc.SetPaymentTerm("31/05/2024")
+			doc.SetCompany(&Contact{Name: "Acme SPRL", Address: &Address{Address: "Rue Neuve 1", PostalCode: "1000", City: "Bruxelles", Country: "BE"}})
+			doc.SetCustomer(&Contact{Name: "Client SA", Address: &Address{Address: "Rue Haute 5", PostalCode: "4000", City: "Liège", Country: "BE"}})
+			doc.AppendItem(&Item{Name: "Consultance", UnitCost: "100", Quantity: "3", Tax: &Tax{Percent: "21"}, Discount: &Discount{Percent: "10"}})
+			doc.SetDiscount(&Discount{Percent: "5"})
+
+			pdf, err := doc.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+
+			if err := os.MkdirAll("../out", 0o750); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+
+			if err := pdf.OutputFileAndClose("../out/invoice_bilingual_" + layout + ".pdf"); err != nil {
+				t.Fatalf("OutputFileAndClose: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewWithAmountInWords(t *testing.T) {
+	doc, err := New(Invoice, &Options{
+		TextTypeInvoice:       "FACTURE",
+		TextAmountInWords:     "Arrêtée la présente facture à la somme de",
+		AmountInWords:         true,
+		AmountInWordsLanguage: WordsLanguageFrench,
+	})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	doc.SetRef("FA-001")
+	doc.SetCompany(&Contact{Name: "Acme SAS", Address: &Address{Address: "1 Rue de la Paix", PostalCode: "75001", City: "Paris"}})
+	doc.SetCustomer(&Contact{Name: "Client SARL", Address: &Address{Address: "5 Rue Neuve", PostalCode: "69001", City: "Lyon"}})
+	doc.AppendItem(&Item{Name: "Formation", UnitCost: "500", Quantity: "2", Tax: &Tax{Percent: "20"}})
+	doc.SetPaymentTerm("30 jours")
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	words, err := doc.TotalInWords()
+	if err != nil {
+		t.Fatalf("TotalInWords: %v", err)
+	}
+	if words != "mille deux cents euros" {
+		t.Fatalf("TotalInWords = %q", words)
+	}
+
+	if err := os.MkdirAll("../out", 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	if err := pdf.OutputFileAndClose("../out/invoice_amount_in_words.pdf"); err != nil {
+		t.Fatalf("OutputFileAndClose: %v", err)
+	}
+
+	doc.Options.AmountInWordsLanguage = "xx"
+	if _, err := doc.Build(); !errors.Is(err, ErrUnsupportedWordsLanguage) {
+		t.Fatalf("expected ErrUnsupportedWordsLanguage, got %v", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	doc, err := New(Invoice, &Options{})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "John Doe", Address: &Address{City: "Paris"}})
+	doc.AppendItem(&Item{Name: "Valid", UnitCost: "10", Quantity: "1"})
+	doc.AppendItem(&Item{UnitCost: "ten", Quantity: "1", Tax: &Tax{Percent: "20", Amount: "3"}})
+	doc.SetDefaultTax(&Tax{Percent: "abc"})
+	doc.SetDiscount(&Discount{Percent: "5", Amount: "10"})
+
+	err = doc.Validate()
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	want := map[string]string{
+		"ref":                      "required",
+		"customer.address.address": "required",
+		"items[1].name":            "required",
+		"items[1].unit_cost":       ErrorCodeInvalidDecimal,
+		"items[1].tax":             ErrorCodeInvalidTax,
+		"default_tax.percent":      ErrorCodeInvalidDecimal,
+		"discount":                 ErrorCodeInvalidDiscount,
+	}
+
+	got := map[string]string{}
+	for _, fe := range verr.Errors {
+		if fe.Message == "" {
+			t.Errorf("%s: empty message", fe.Path)
+		}
+		got[fe.Path] = fe.Code
+	}
+
+	for path, code := range want {
+		if got[path] != code {
+			t.Errorf("%s: got code %q, want %q", path, got[path], code)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got errors %v, want %v", got, want)
+	}
+
+	if !errors.Is(err, ErrInvalidDiscount) || !errors.Is(err, ErrInvalidTax) {
+		t.Errorf("expected err to wrap ErrInvalidDiscount and ErrInvalidTax")
+	}
+
+	// Prepare errors keep matching the sentinel errors
+	if err := (&Tax{}).Prepare(); !errors.Is(err, ErrInvalidTax) || !errors.As(err, &verr) {
+		t.Errorf("Tax.Prepare: got %v", err)
+	}
+	if err := (&Discount{Percent: "5", Amount: "1"}).Prepare(); !errors.Is(err, ErrInvalidDiscount) || !errors.As(err, &verr) {
+		t.Errorf("Discount.Prepare: got %v", err)
+	}
+}
+
+func TestRenderRepeatedly(t *testing.T) {
+	doc, err := New(Invoice, &Options{})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+	doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "5 Side St", PostalCode: "94105", City: "San Francisco"}})
+	for i := 0; i < 40; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1", Tax: &Tax{Percent: "20"}})
+	}
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	pageCount := pdf.PageCount()
+
+	again, err := doc.Build()
+	if err != nil {
+		t.Fatalf("second Build: %v", err)
+	}
+	if again == pdf {
+		t.Fatalf("Build must render into a new pdf")
+	}
+	if again.PageCount() != pageCount {
+		t.Fatalf("second Build has %d pages, want %d", again.PageCount(), pageCount)
+	}
+	if doc.Pdf() != again {
+		t.Fatalf("Pdf must return the pdf of the last render")
+	}
+
+	original, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	doc.SetHeader(&HeaderFooter{Text: "<center>COPY</center>"})
+	var copyBuf bytes.Buffer
+	if err := doc.Render(&copyBuf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if !bytes.HasPrefix(original, []byte("%PDF-")) || !bytes.HasPrefix(copyBuf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("Bytes and Render must produce PDF documents")
+	}
+}
+
+func TestOnPdfInit(t *testing.T) {
+	doc, err := New(Invoice, &Options{})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	calls := 0
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		calls++
+		pdf.SetAuthor("Acme Corp", true)
+	})
+
+	doc.SetRef("INV-002")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	for i := 0; i < 2; i++ {
+		if _, err := doc.Bytes(); err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+	}
+
+	// Once on the pdf created by New, then on the pdf of the second render
+	// and on the page break probes
+	if calls < 2 {
+		t.Fatalf("OnPdfInit func called %d times, want at least 2", calls)
+	}
+}
+
+func TestConcurrentStyles(t *testing.T) {
+	styles := []Style{
+		{},
+		{BaseFontSize: 10, SmallFontSize: 8, LargeFontSize: 12, TitleFontSize: 18, Margin: 15},
+		{BaseFontSize: 6, ItemRowPadding: 1, SectionSpacing: 4},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(styles)*4)
+	for i := 0; i < 4; i++ {
+		for _, style := range styles {
+			wg.Add(1)
+			go func(style Style) {
+				defer wg.Done()
+
+				doc, err := New(Invoice, &Options{Style: style})
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				doc.SetRef("INV-001")
+				doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+				doc.SetCustomer(&Contact{Name: "Client Inc"})
+				doc.AppendItem(&Item{Name: "Service", Description: "Monthly plan", UnitCost: "10", Quantity: "3", Tax: &Tax{Percent: "20"}, Discount: &Discount{Percent: "5"}})
+
+				if _, err := doc.Bytes(); err != nil {
+					errs <- err
+				}
+			}(style)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("render: %v", err)
+	}
+}
+
+func TestStyleDefaults(t *testing.T) {
+	doc, err := New(Invoice, &Options{Style: Style{BaseFontSize: 9}})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	st := doc.Options.Style
+	if st.BaseFontSize != 9 {
+		t.Fatalf("BaseFontSize = %v, want 9", st.BaseFontSize)
+	}
+	if st.SmallFontSize != 7 || st.LargeFontSize != 10 || st.Margin != 10 || st.ItemRowPadding != 3 {
+		t.Fatalf("unexpected default style %+v", st)
+	}
+}
+
+func TestDeprecatedFontSizes(t *testing.T) {
+	defer func(size float64) { BaseTextFontSize = size }(BaseTextFontSize)
+	BaseTextFontSize = 10
+
+	doc, err := New(Invoice, &Options{Style: Style{SmallFontSize: 9}})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if st := doc.Options.Style; st.BaseFontSize != 10 || st.SmallFontSize != 9 || st.LargeFontSize != 10 {
+		t.Fatalf("unexpected style %+v", st)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	refs := map[*Item]string{}
+	doc, err := New(Invoice, &Options{
+		Columns: []*Column{
+			{Key: "ref", Title: "Ref.", Width: 20, Value: func(_ *Document, item *Item) Cell {
+				return Cell{Text: refs[item]}
+			}},
+			{Key: ColumnName, Flex: 2},
+			{Key: ColumnQuantity, Width: 15, Align: AlignRight},
+			{Key: ColumnDiscount, Width: 20, HideWhenEmpty: true},
+			{Key: ColumnTotalTTC},
+		},
+	})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	for _, ref := range []string{"A-1", "B-2"} {
+		item := &Item{Name: "Widget " + ref, Description: "Blue", UnitCost: "12.50", Quantity: "4"}
+		refs[item] = ref
+		doc.AppendItem(item)
+	}
+
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cols := doc.layoutColumns(10, 200)
+	keys := make([]string, len(cols))
+	for i, col := range cols {
+		keys[i] = col.Key
+	}
+	if got := strings.Join(keys, ","); got != "ref,name,quantity,total_ttc" {
+		t.Fatalf("visible columns = %s", got)
+	}
+
+	// 190 mm minus 35 mm of fixed columns, shared 2:1 by name and total
+	if math.Abs(cols[1].W-155.0*2/3) > 1e-9 || math.Abs(cols[3].W-155.0/3) > 1e-9 {
+		t.Fatalf("flex widths = %v, %v", cols[1].W, cols[3].W)
+	}
+	if math.Abs(cols[3].X+cols[3].W-200) > 1e-9 {
+		t.Fatalf("table ends at %v, want 200", cols[3].X+cols[3].W)
+	}
+	if cols[1].Label != "Name" {
+		t.Fatalf("built-in column title = %q", cols[1].Label)
+	}
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if err := os.MkdirAll("../out", 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := pdf.OutputFileAndClose("../out/invoice_columns.pdf"); err != nil {
+		t.Fatalf("OutputFileAndClose: %v", err)
+	}
+
+	doc.Items[0].Discount = &Discount{Percent: "10"}
+	if cols := doc.layoutColumns(10, 200); len(cols) != 5 {
+		t.Fatalf("discount column must show once an item has a discount, got %d columns", len(cols))
+	}
+
+	doc.Options.Columns = append(doc.Options.Columns, &Column{Key: "unit"})
+	var verr *ValidationError
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.columns[5].key" {
+		t.Fatalf("expected an unknown column error, got %v", err)
+	}
+}
+
+func TestPageSizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          string
+		orientation   string
+		width, height float64
+	}{
+		{"letter", PageSizeLetter, OrientationPortrait, 215.9, 279.4},
+		{"a5_landscape", PageSizeA5, OrientationLandscape, 210, 148.5},
+		{"custom", PageSizeCustom, OrientationPortrait, 100, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &Options{PageSize: tt.size, PageOrientation: tt.orientation}
+			if tt.size == PageSizeCustom {
+				opts.PageWidth, opts.PageHeight = tt.width, tt.height
+			}
+			doc, err := New(DeliveryNote, opts)
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+
+			doc.SetRef("DN-001")
+			doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+			doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "5 Side St", PostalCode: "94105", City: "San Francisco"}})
+			doc.SetFooter(&HeaderFooter{Text: "Acme Corp", Pagination: true})
+			for i := 0; i < 30; i++ {
+				doc.AppendItem(&Item{Name: "Pallet", UnitCost: "0", Quantity: "1"})
+			}
+
+			pdf, err := doc.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+
+			width, height := pdf.GetPageSize()
+			if math.Abs(width-tt.width) > 0.1 || math.Abs(height-tt.height) > 0.1 {
+				t.Fatalf("page size = %.1f × %.1f, want %.1f × %.1f", width, height, tt.width, tt.height)
+			}
+			if got := doc.rightColumnX() + rightColumnWidth; math.Abs(got-(width-10)) > 1e-9 {
+				t.Fatalf("right column ends at %v, want %v", got, width-10)
+			}
+			if got := doc.maxPageHeight(); math.Abs(got-(height-footerReserve)) > 1e-9 {
+				t.Fatalf("maxPageHeight = %v", got)
+			}
+			if pdf.PageCount() < 2 {
+				t.Fatalf("expected items to span several pages, got %d", pdf.PageCount())
+			}
+
+			if err := os.MkdirAll("../out", 0o750); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+			if err := pdf.OutputFileAndClose("../out/delivery_note_" + tt.name + ".pdf"); err != nil {
+				t.Fatalf("OutputFileAndClose: %v", err)
+			}
+		})
+	}
+
+	doc, err := New(Invoice, &Options{PageSize: PageSizeCustom})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+
+	var verr *ValidationError
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.page_width" {
+		t.Fatalf("expected a page width error, got %v", err)
+	}
+
+	doc.Options.PageSize = "B5"
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.page_size" {
+		t.Fatalf("expected a page size error, got %v", err)
+	}
+}
+
+// stampTheme is a custom theme counting the table headers it draws
+type stampTheme struct {
+	ClassicTheme
+	headers int
+}
+
+func (th *stampTheme) TableHeader(doc *Document) {
+	th.headers++
+	th.ClassicTheme.TableHeader(doc)
+}
+
+func (th *stampTheme) Title(doc *Document) {
+	x, w := doc.RightColumn()
+	doc.Pdf().SetXY(x, doc.Options.Style.MarginTop)
+	doc.Pdf().SetFont(doc.Options.BoldFont, "B", 16)
+	doc.Pdf().CellFormat(w, 10, doc.EncodeString(doc.TypeLabel(doc.Options)+" — "+doc.FormatMoney(doc.TotalWithTax())), "0", 0, "R", false, 0, "")
+}
+
+func TestThemes(t *testing.T) {
+	newDoc := func(theme string) *Document {
+		doc, err := New(Invoice, &Options{Theme: theme})
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		doc.SetRef("INV-001")
+		doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+		doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "5 Side St", PostalCode: "94105", City: "San Francisco"}})
+		doc.SetNotes("Thank you for your business.")
+		doc.SetPaymentTerm("30 days")
+		doc.SetDefaultTax(&Tax{Percent: "20"})
+		for i := 0; i < 40; i++ {
+			doc.AppendItem(&Item{Name: "Consulting", UnitCost: "100", Quantity: "2", Discount: &Discount{Percent: "5"}})
+		}
+		return doc
+	}
+
+	if err := os.MkdirAll("../out", 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	for _, name := range []string{ThemeClassic, ThemeMinimal, ThemeModern} {
+		pdf, err := newDoc(name).Build()
+		if err != nil {
+			t.Fatalf("Build %s: %v", name, err)
+		}
+		if err := pdf.OutputFileAndClose("../out/invoice_theme_" + name + ".pdf"); err != nil {
+			t.Fatalf("OutputFileAndClose: %v", err)
+		}
+	}
+
+	theme := &stampTheme{}
+	doc := newDoc("").SetTheme(theme)
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build custom: %v", err)
+	}
+	if pdf.PageCount() < 2 || theme.headers != pdf.PageCount() {
+		t.Fatalf("custom theme drew %d table headers on %d pages", theme.headers, pdf.PageCount())
+	}
+	if err := pdf.OutputFileAndClose("../out/invoice_theme_custom.pdf"); err != nil {
+		t.Fatalf("OutputFileAndClose: %v", err)
+	}
+
+	var verr *ValidationError
+	if err := newDoc("fancy").Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.theme" {
+		t.Fatalf("expected a theme error, got %v", err)
+	}
+	if err := newDoc("fancy").SetTheme(&stampTheme{}).Validate(); err != nil {
+		t.Fatalf("custom theme must ignore Options.Theme, got %v", err)
+	}
+}
+
+func TestRTL(t *testing.T) {
+	if got := shapeArabic("سلام"); got != "\uFEB3\uFEFC\uFEE1" {
+		t.Fatalf("shapeArabic: got %U", []rune(got))
+	}
+	for in, want := range map[string]string{
+		"abc":            "abc",
+		"שלום 123":       "123 םולש",
+		"فاتورة INV-001": "INV-001 ةروتاف",
+	} {
+		if got := visualOrder(in); got != want {
+			t.Fatalf("visualOrder(%q): got %q, want %q", in, got, want)
+		}
+	}
+
+	doc, err := New(Invoice, &Options{
+		Direction:            DirectionRTL,
+		Font:                 dejavu.Family,
+		BoldFont:             dejavu.Family,
+		CurrencySymbol:       "₪ ",
+		TextTypeInvoice:      "חשבונית",
+		TextRefTitle:         "מספר",
+		TextDateTitle:        "תאריך",
+		TextItemsNameTitle:   "תיאור",
+		TextTotalTotal:       "סה\"כ",
+		TextTotalWithTax:     "סה\"כ לתשלום",
+		TextPaymentTermTitle: "תנאי תשלום",
+	})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	doc.OnPdfInit(dejavu.Register)
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "شركة المثال", Address: &Address{Address: "شارع الملك فهد 12", PostalCode: "11564", City: "الرياض"}})
+	doc.SetCustomer(&Contact{Name: "לקוח בע\"מ", Address: &Address{Address: "רחוב הרצל 5", PostalCode: "6100000", City: "תל אביב"}})
+	doc.SetDescription("خدمات استشارية لشهر مارس")
+	doc.SetNotes("<b>شكراً</b> لتعاملكم معنا")
+	doc.SetPaymentTerm("30 יום")
+	doc.SetDefaultTax(&Tax{Percent: "17"})
+	doc.AppendItem(&Item{Name: "ייעוץ טכני — API v2", UnitCost: "1200", Quantity: "3"})
+	doc.AppendItem(&Item{Name: "استضافة سحابية", UnitCost: "250", Quantity: "1"})
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if pageWidth, _ := doc.pdf.GetPageSize(); doc.mirrorX(10, 70) != pageWidth-80 {
+		t.Fatalf("mirrorX: got %v", doc.mirrorX(10, 70))
+	}
+	if a := doc.mirrorAlign("BR"); a != "BL" {
+		t.Fatalf("mirrorAlign: got %q", a)
+	}
+
+	// Amounts keep their order; in right to left lines, numbers stay whole
+	// and a trailing Latin run is drawn at the left end of the line
+	for in, want := range map[string]string{
+		"₪ 3 600.00":          "₪ 3 600.00",
+		"€ 1 234 567.89":      "€ 1 234 567.89",
+		"20 %":                "20 %",
+		"-10 % / -€ 12.00":    "-10 % / -€ 12.00",
+		"סה\"כ: ₪ 3 600.00":   "₪ 3 600.00 :כ\"הס",
+		"מע\"מ 17 %":          "17 % מ\"עמ",
+		"ייעוץ טכני — API v2": "API v2 — ינכט ץועיי",
+	} {
+		if got := doc.encodeString(in); got != want {
+			t.Errorf("encodeString(%q): got %q, want %q", in, got, want)
+		}
+	}
+
+	if err := pdf.OutputFileAndClose("../out/invoice_rtl.pdf"); err != nil {
+		t.Fatalf("OutputFileAndClose: %v", err)
+	}
+
+	var verr *ValidationError
+	doc.Options.Direction = "up"
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.direction" {
+		t.Fatalf("expected a direction error, got %v", err)
+	}
+}
+
+func TestFallbackFonts(t *testing.T) {
+	doc, err := New(Invoice, &Options{
+		FallbackFonts: []*FontFamily{{Name: dejavu.Family, Regular: dejavu.Regular, Bold: dejavu.Bold}},
+	})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	doc.SetRef("INV-001")
+	doc.SetHeader(&HeaderFooter{Text: "<center>Acme Corp — אקמה בע\"מ</center>"})
+	doc.SetFooter(&HeaderFooter{Text: "Thank you — شكراً", Pagination: true})
+	doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+	doc.SetCustomer(&Contact{Name: "שלום Trading", Address: &Address{Address: "רחוב הרצל 5", PostalCode: "6100000", City: "Tel Aviv"}})
+	doc.SetNotes("Paid by <b>העברה בנקאית</b>, thank you")
+	doc.AppendItem(&Item{Name: "Consulting — ייעוץ טכני עבור פרויקט ההטמעה של מערכת החשבונות החדשה", UnitCost: "100", Quantity: "2"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_fallback_fonts.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	doc.pdf.SetFont(doc.Options.Font, "", 12)
+	runs := doc.fontRuns("Acme שלום Corp")
+	want := []fontRun{{"roboto", "Acme "}, {"dejavu", "שלום "}, {"roboto", "Corp"}}
+	if len(runs) != len(want) {
+		t.Fatalf("fontRuns: got %v, want %v", runs, want)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Fatalf("fontRuns: got %v, want %v", runs, want)
+		}
+	}
+
+	var verr *ValidationError
+	doc.Options.FallbackFonts[0].Bold = []byte("not a font")
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.fallback_fonts[0].bold" {
+		t.Fatalf("expected a bold font error, got %v", err)
+	}
+	doc.Options.FallbackFonts[0] = &FontFamily{Name: "Empty"}
+	if err := doc.Validate(); !errors.As(err, &verr) || verr.Errors[0].Path != "options.fallback_fonts[0].regular" {
+		t.Fatalf("expected a regular font error, got %v", err)
+	}
+}
+
+func TestFonts(t *testing.T) {
+	fonts := func() *FontFamily {
+		return &FontFamily{Name: dejavu.Family, Regular: dejavu.Regular, Bold: dejavu.Bold, Italic: dejavu.Italic, BoldItalic: dejavu.BoldItalic}
+	}
+
+	doc, err := New(Invoice, &Options{Fonts: fonts()})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if doc.Options.Font != dejavu.Family || doc.Options.BoldFont != dejavu.Family {
+		t.Fatalf("Font and BoldFont not set from Fonts: %q, %q", doc.Options.Font, doc.Options.BoldFont)
+	}
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp", AddtionnalInfo: []string{"<i>Registered in Ελλάδα</i>"}})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetNotes("<i>Thank you</i> — <b><i>payment due in 30 days</i></b>")
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_fonts.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	missing := fonts()
+	missing.Italic = nil
+	missing.BoldItalic = []byte("not a font")
+	_, err = New(Invoice, &Options{Fonts: missing})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 {
+		t.Fatalf("expected two font errors, got %v", err)
+	}
+	if verr.Errors[0].Path != "options.fonts.italic" || verr.Errors[0].Code != "required" {
+		t.Fatalf("expected a missing italic error, got %v", verr.Errors[0])
+	}
+	if verr.Errors[1].Path != "options.fonts.bold_italic" || verr.Errors[1].Code != ErrorCodeInvalid {
+		t.Fatalf("expected an invalid bold italic error, got %v", verr.Errors[1])
+	}
+}
+
+func TestItalicWithoutItalicFont(t *testing.T) {
+	doc, _ := New(Invoice, &Options{})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp", AddtionnalInfo: []string{"<i>Registered in France</i>"}})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetNotes("a <i>b</i> <b><i>c</i></b>")
+	doc.SetFooter(&HeaderFooter{Text: "<i>Thank you</i>"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	// The embedded Roboto has no italic: italic text is drawn upright
+	if _, err := doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if style := doc.fontStyle("Roboto", "BIU"); style != "BU" {
+		t.Fatalf("expected Roboto italic to be dropped, got %q", style)
+	}
+
+	// Families with an italic keep it
+	doc, _ = New(Invoice, &Options{
+		Fonts: &FontFamily{Name: dejavu.Family, Regular: dejavu.Regular, Bold: dejavu.Bold, Italic: dejavu.Italic, BoldItalic: dejavu.BoldItalic},
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetNotes("a <i>b</i>")
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	if _, err := doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if style := doc.fontStyle(dejavu.Family, "I"); style != "I" {
+		t.Fatalf("expected the italic style to be kept, got %q", style)
+	}
+}
+
+func TestLogos(t *testing.T) {
+	svgLogo := []byte(`<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 200 50">
+  <defs><linearGradient id="g"><stop offset="0"/></linearGradient></defs>
+  <rect x="2" y="2" width="196" height="46" rx="8" fill="none" stroke="#1a237e" stroke-width="3"/>
+  <g transform="translate(25 25)" style="fill:#e53935">
+    <circle r="15"/>
+    <path d="M-8-8l16 16m0-16L-8 8" stroke="white" stroke-width="3"/>
+  </g>
+  <path d="M50 35 Q60 10 70 35 T90 35 A10 5 30 1 1 110 35z" fill="#1a237e" fill-rule="evenodd" opacity=".8"/>
+  <polygon points="130,10 150,40 170,10"/>
+</svg>`)
+
+	img, err := parseSVG(svgLogo)
+	if err != nil {
+		t.Fatalf("parseSVG: %v", err)
+	}
+	if img.width != 200 || img.height != 50 || len(img.shapes) != 5 {
+		t.Fatalf("unexpected svg: %vx%v with %d shapes", img.width, img.height, len(img.shapes))
+	}
+	circle := img.shapes[1]
+	if circle.fill[0] != 0xe5 || circle.path[0].args[0] != 10 || circle.path[0].args[1] != 25 {
+		t.Fatalf("group style or transform not applied: %+v", circle)
+	}
+	if end := img.shapes[3].path[len(img.shapes[3].path)-2]; end.cmd != 'C' || end.args[4] != 110 || end.args[5] != 35 {
+		t.Fatalf("arc does not end at its end point: %+v", end)
+	}
+
+	if w, h := fitLogo(4, contactWidth, logoHeight); w != contactWidth || h != contactWidth/4 {
+		t.Fatalf("wide logo not capped at the block width: %vx%v", w, h)
+	}
+
+	var wide bytes.Buffer
+	if err := png.Encode(&wide, image.NewRGBA(image.Rect(0, 0, 400, 100))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	doc, _ := New(Invoice, &Options{})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{
+		Name:       "Acme Corp",
+		Logo:       svgLogo,
+		LogoLayout: &LogoLayout{MaxWidth: 500, MaxHeight: 40},
+	})
+	doc.SetCustomer(&Contact{
+		Name:       "Client Inc",
+		Logo:       wide.Bytes(),
+		LogoLayout: &LogoLayout{MaxHeight: 10, Align: AlignCenter},
+	})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	if _, err := doc.Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	// The company logo stops before the title column
+	left, _, _, _ := doc.pdf.GetMargins()
+	maxWidth := doc.rightColumnX() - left - logoGap
+	if h := doc.appendContactLogo(doc.Company, left, 0, contactWidth, maxWidth); h != maxWidth/4 {
+		t.Fatalf("company logo height %v, want %v", h, maxWidth/4)
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_logos.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// Header logos are drawn on every page, with or without header text
+	doc.Company.LogoLayout = &LogoLayout{InHeader: true}
+	doc.Customer.LogoLayout = &LogoLayout{InHeader: true}
+	for i := 0; i < 40; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+	if _, err := doc.Bytes(); err != nil {
+		t.Fatalf("Bytes with header logos: %v", err)
+	}
+	doc.SetHeader(&HeaderFooter{Text: "<center>Acme Corp</center>"})
+	out, err = doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes with header logos and text: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_header_logos.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	doc.Company.LogoLayout = &LogoLayout{MaxWidth: -1, Align: "top"}
+	err = doc.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 {
+		t.Fatalf("expected two logo layout errors, got %v", err)
+	}
+	if verr.Errors[0].Path != "company.logo_layout.max_width" || verr.Errors[1].Path != "company.logo_layout.align" {
+		t.Fatalf("unexpected logo layout errors %v", verr.Errors)
+	}
+}
+
+func TestWatermarks(t *testing.T) {
+	doc, _ := New(Quotation, &Options{})
+	doc.SetRef("QUO-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	for i := 0; i < 40; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+
+	// Under the content, text watermarks need no transparency
+	doc.SetWatermark(&Watermark{Text: "DRAFT", Rotation: 45})
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if bytes.Contains(out, []byte("/ca ")) {
+		t.Fatalf("watermark under the content uses transparency")
+	}
+	if err := os.WriteFile("../out/quotation_draft.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	doc.SetType(Invoice)
+	doc.SetHeader(&HeaderFooter{Text: "Acme Corp"})
+	doc.SetFooter(&HeaderFooter{Text: "Thank you", Pagination: true})
+	doc.SetWatermark(&Watermark{
+		Text:          "PAID on 2026-10-01",
+		Border:        true,
+		Rotation:      20,
+		Opacity:       0.8,
+		FirstPageOnly: true,
+		OverContent:   true,
+	})
+	out, err = doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if !bytes.Contains(out, []byte("/ca 0.8")) {
+		t.Fatalf("watermark over the content is not transparent")
+	}
+	if err := os.WriteFile("../out/invoice_paid.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	logo, err := os.ReadFile("../support/example_logo.png")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	doc.SetWatermark(&Watermark{Image: logo, Width: 120, Opacity: 0.1})
+	if _, err := doc.Bytes(); err != nil {
+		t.Fatalf("Bytes with an image watermark: %v", err)
+	}
+
+	doc.SetWatermark(&Watermark{Opacity: 2})
+	err = doc.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 {
+		t.Fatalf("expected two watermark errors, got %v", err)
+	}
+	if verr.Errors[0].Path != "watermark.opacity" || verr.Errors[1].Path != "watermark" {
+		t.Fatalf("unexpected watermark errors %v", verr.Errors)
+	}
+}
+
+// letterheadPDF returns a one page A4 letterhead with a band of height top
+func letterheadPDF(t *testing.T, top float64) []byte {
+	t.Helper()
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	pdf.SetFillColor(26, 35, 126)
+	pdf.Rect(0, 0, 210, top-5, "F")
+	pdf.Rect(0, 280, 210, 17, "F")
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.Text(15, top/2, "ACME CORP")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("letterhead: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLetterhead(t *testing.T) {
+	doc, _ := New(Invoice, &Options{
+		Letterhead: &Letterhead{
+			FirstPage: letterheadPDF(t, 40),
+			NextPages: letterheadPDF(t, 20),
+			Top:       45,
+			NextTop:   25,
+			Bottom:    25,
+			Left:      15,
+		},
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	for i := 0; i < 40; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+
+	if _, err := doc.Build(); !errors.Is(err, ErrRenderRequired) {
+		t.Fatalf("expected ErrRenderRequired from Build, got %v", err)
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if st := doc.style(); st.Margin != 15 || st.MarginTop != 45 || st.MarginBottom != 25 {
+		t.Fatalf("letterhead areas do not set the margins: %+v", st)
+	}
+	if doc.Options.Style.Margin != 10 {
+		t.Fatalf("letterhead changed the document style")
+	}
+	if err := os.WriteFile("../out/invoice_letterhead.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ctx, err := pdfcpuapi.ReadValidateAndOptimize(bytes.NewReader(out), pdfcpuConf())
+	if err != nil {
+		t.Fatalf("ReadValidateAndOptimize: %v", err)
+	}
+	if ctx.PageCount < 2 {
+		t.Fatalf("expected several pages, got %d", ctx.PageCount)
+	}
+	root, _ := ctx.Catalog()
+	props, _ := ctx.DereferenceDict(root["OCProperties"])
+	config, _ := ctx.DereferenceDict(props["D"])
+	if _, ok := config["AS"]; ok || config["Name"] == nil {
+		t.Fatalf("optional content configuration is not PDF/A compliant: %v", config)
+	}
+
+	// Watermarks under the content let the letterhead show through
+	doc.SetWatermark(&Watermark{Text: "DRAFT"})
+	out, err = doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	ctx, err = pdfcpuapi.ReadValidateAndOptimize(bytes.NewReader(out), pdfcpuConf())
+	if err != nil {
+		t.Fatalf("ReadValidateAndOptimize: %v", err)
+	}
+	translucent := false
+	for _, entry := range ctx.Table {
+		if d, ok := entry.Object.(types.Dict); ok && d["ca"] != nil && d["ca"].String() == "0.30" {
+			translucent = true
+		}
+	}
+	if !translucent {
+		t.Fatalf("watermark under the content is opaque over the letterhead")
+	}
+
+	doc.Options.Letterhead = &Letterhead{FirstPage: []byte("not a PDF")}
+	err = doc.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "options.letterhead.first_page" {
+		t.Fatalf("expected an invalid letterhead error, got %v", err)
+	}
+}
+
+func TestAppendices(t *testing.T) {
+	terms := &bytes.Buffer{}
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(terms, "<b>Article %d.</b> The customer accepts these general terms of sale without reservation. Any late payment is charged interest at three times the legal rate, plus a fixed recovery fee of 40 EUR.<br><br>", i)
+	}
+	attached := letterheadPDF(t, 40)
+
+	doc, _ := New(Quotation, &Options{})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("QUO-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	doc.SetFooter(&HeaderFooter{Text: "Acme Corp", Pagination: true})
+	doc.AddAppendix(&Appendix{Title: "General terms of sale", Text: terms.String()})
+	doc.AddAppendix(&Appendix{PDF: attached})
+
+	// PDF appendices are merged when the document is written
+	if _, err := doc.Build(); !errors.Is(err, ErrRenderRequired) {
+		t.Fatalf("expected ErrRenderRequired from Build, got %v", err)
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	built := doc.Pdf().PageCount()
+	if built < 3 {
+		t.Fatalf("expected the terms of sale to flow over several pages, got %d pages", built)
+	}
+	if err := os.WriteFile("../out/quotation_appendices.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	count, err := pdfcpuapi.PageCount(bytes.NewReader(out), pdfcpuConf())
+	if err != nil {
+		t.Fatalf("PageCount: %v", err)
+	}
+	if count != built+1 {
+		t.Fatalf("expected %d pages with the merged PDF, got %d", built+1, count)
+	}
+	if !bytes.Contains(out, utf16(fmt.Sprintf("Page 1/%d", count))) {
+		t.Fatalf("written PDF is not numbered over its %d pages", count)
+	}
+
+	doc.Appendices = []*Appendix{{Title: "Empty"}, {PDF: []byte("not a PDF")}}
+	err = doc.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 ||
+		verr.Errors[0].Path != "appendices[0]" || verr.Errors[1].Path != "appendices[1].pdf" {
+		t.Fatalf("expected invalid appendix errors, got %v", err)
+	}
+}
+
+func TestAcceptance(t *testing.T) {
+	doc, _ := New(Quotation, &Options{Acceptance: true, AcceptanceFormFields: true})
+	doc.SetRef("QUO-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetPaymentTerm("30% on order")
+	for i := 0; i < 16; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+
+	if _, err := doc.Build(); !errors.Is(err, ErrRenderRequired) {
+		t.Fatalf("expected ErrRenderRequired from Build, got %v", err)
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/quotation_acceptance.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if len(doc.formFields) != 3 {
+		t.Fatalf("expected name, date and signature fields, got %d", len(doc.formFields))
+	}
+	for _, f := range doc.formFields {
+		if f.page != doc.formFields[0].page {
+			t.Fatalf("acceptance block split across pages: %+v", doc.formFields)
+		}
+	}
+
+	ctx, err := pdfcpuapi.ReadValidateAndOptimize(bytes.NewReader(out), pdfcpuConf())
+	if err != nil {
+		t.Fatalf("ReadValidateAndOptimize: %v", err)
+	}
+	root, _ := ctx.Catalog()
+	form, _ := ctx.DereferenceDict(root["AcroForm"])
+	fields, _ := ctx.DereferenceArray(form["Fields"])
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 form fields in the PDF, got %v", form)
+	}
+
+	// Invoices have no acceptance block
+	doc.SetType(Invoice)
+	if _, err := doc.Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(doc.formFields) != 0 {
+		t.Fatalf("invoice has acceptance fields")
+	}
+}
+
+// utf16 returns the ASCII text s as written in uncompressed PDFs by the
+// embedded fonts
+func utf16(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		b = append(b, 0, byte(r))
+	}
+	return b
+}
+
+func TestCarriedForward(t *testing.T) {
+	doc, _ := New(Invoice, &Options{CarriedForward: true})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetDefaultTax(&Tax{Percent: "20"})
+	for i := 0; i < 60; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	pages := pdf.PageCount()
+	if pages < 3 {
+		t.Fatalf("expected several pages, got %d", pages)
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_carried_forward.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for _, label := range []string{"Carried forward", "Brought forward"} {
+		if n := bytes.Count(out, utf16(label)); n != pages-1 {
+			t.Fatalf("expected %d %q rows, got %d", pages-1, label, n)
+		}
+	}
+
+	// Without the option, the table has no subtotal rows
+	doc.Options.CarriedForward = false
+	if out, err = doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if bytes.Contains(out, utf16("Carried forward")) {
+		t.Fatalf("unexpected carried forward row")
+	}
+}
+
+func TestHeaderFooterPageContext(t *testing.T) {
+	var banner bytes.Buffer
+	if err := png.Encode(&banner, image.NewRGBA(image.Rect(0, 0, 300, 50))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	doc, _ := New(Invoice, &Options{})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	for i := 0; i < 40; i++ {
+		doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	}
+
+	var contexts []PageContext
+	firstPageCalls := 0
+	doc.SetHeader(&HeaderFooter{
+		Text:  "Acme Corp",
+		Image: banner.Bytes(),
+		Func: func(ctx *PageContext) {
+			contexts = append(contexts, *ctx)
+		},
+		FirstPage: &HeaderFooter{
+			Func: func(ctx *PageContext) {
+				firstPageCalls++
+			},
+		},
+	})
+	doc.SetFooter(&HeaderFooter{
+		Pagination:       true,
+		PaginationFormat: "Seite {page} von {total}",
+		PaginationAlign:  AlignCenter,
+	})
+
+	pdf, err := doc.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	pages := pdf.PageCount()
+	if pages < 2 {
+		t.Fatalf("expected several pages, got %d", pages)
+	}
+
+	// The first render counts the pages, the second one has the total
+	if firstPageCalls != 2 {
+		t.Fatalf("expected the first page header twice, got %d", firstPageCalls)
+	}
+	last := contexts[len(contexts)-(pages-1):]
+	for i, ctx := range last {
+		if ctx.Page != i+2 || ctx.Total != pages || ctx.First || ctx.Last != (ctx.Page == pages) || ctx.Pdf != pdf {
+			t.Fatalf("unexpected page context: %+v", ctx)
+		}
+	}
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_header_footer.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if !bytes.Contains(out, utf16("Seite 1 von ")) {
+		t.Fatalf("templated pagination not found")
+	}
+
+	// Headers draw the pagination when asked only
+	doc.SetHeader(&HeaderFooter{Text: "Acme Corp"})
+	doc.SetFooter(nil)
+	if out, err = doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if bytes.Contains(out, utf16("Page 1/")) {
+		t.Fatalf("header without pagination draws the page number")
+	}
+
+	// Custom footers keep the header
+	footerCalls := 0
+	footer := &HeaderFooter{UseCustomFunc: true}
+	footer.ApplyFunc(nil, func() {
+		footerCalls++
+	})
+	doc.SetHeader(&HeaderFooter{Text: "Acme Corp", Pagination: true})
+	doc.SetFooter(footer)
+	if out, err = doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if footerCalls != pages || !bytes.Contains(out, utf16("Page 1/")) {
+		t.Fatalf("custom footer replaced the header (%d footer calls)", footerCalls)
+	}
+
+	doc.SetHeader(&HeaderFooter{PaginationAlign: "top"})
+	err = doc.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "header.pagination_align" {
+		t.Fatalf("expected an invalid pagination align error, got %v", err)
+	}
+}
+
+func TestApplyFuncStalePdf(t *testing.T) {
+	doc, _ := New(Invoice, &Options{})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	// A captured PDF is only drawn by the first render
+	pdf := doc.Pdf()
+	footer := &HeaderFooter{UseCustomFunc: true}
+	footer.ApplyFunc(pdf, func() {
+		pdf.Text(10, 290, "Acme Corp")
+	})
+	doc.SetFooter(footer)
+	if _, err := doc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if _, err := doc.Bytes(); !errors.Is(err, ErrStalePdf) {
+		t.Fatalf("expected ErrStalePdf from the second render, got %v", err)
+	}
+
+	// Functions calling Document.Pdf draw every render
+	calls := 0
+	footer.ApplyFunc(nil, func() {
+		calls++
+		doc.Pdf().Text(10, 290, "Acme Corp")
+	})
+	for range 2 {
+		if _, err := doc.Bytes(); err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 footer calls, got %d", calls)
+	}
+}
+
+func TestShipTo(t *testing.T) {
+	doc, _ := New(Invoice, &Options{})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "1 Main Street", City: "Paris"}})
+	doc.SetShipTo(&Contact{Name: "Client Warehouse", Address: &Address{Address: "12 Dock Road", City: "Le Havre"}})
+	doc.AppendItem(&Item{Name: "Crate", UnitCost: "10", Quantity: "4"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_ship_to.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for _, text := range []string{"Ship to", "Client Warehouse", "Client Inc"} {
+		if !bytes.Contains(out, utf16(text)) {
+			t.Fatalf("PDF does not contain %q", text)
+		}
+	}
+
+	// Delivery notes address the ship-to contact, the customer is billed
+	doc.SetType(DeliveryNote)
+	txt, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text: %v", err)
+	}
+	shipTo, billTo := strings.Index(txt, "Client Warehouse"), strings.Index(txt, "Bill to:")
+	if shipTo < 0 || billTo < shipTo || !strings.Contains(txt[billTo:], "Client Inc") {
+		t.Fatalf("unexpected delivery note contacts:\n%s", txt)
+	}
+}
+
+func TestCompanyContactNarrowPage(t *testing.T) {
+	doc, err := New(Invoice, &Options{PageSize: PageSizeA5})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) { pdf.SetCompression(false) })
+
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp", Address: &Address{Address: "1 Main St", PostalCode: "10001", City: "New York"}})
+	doc.SetCustomer(&Contact{Name: "Client Inc", Address: &Address{Address: "5 Side St", PostalCode: "94105", City: "San Francisco"}})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The company name band, at the left margin, stops before the title column
+	k := doc.pdf.GetConversionRatio()
+	left, right := fmt.Sprintf("%.2f", doc.style().Margin*k), doc.rightColumnX()*k
+	found := false
+	for _, line := range strings.Split(string(out), "\n") {
+		var x, y, w, h float64
+		if n, _ := fmt.Sscanf(line, "%f %f %f %f re f", &x, &y, &w, &h); n != 4 || fmt.Sprintf("%.2f", x) != left || fmt.Sprintf("%.2f", h) != fmt.Sprintf("%.2f", -8*k) {
+			continue
+		}
+		found = true
+		if x+w > right {
+			t.Fatalf("company block ends at %.2f pt, over the title column at %.2f pt", x+w, right)
+		}
+	}
+	if !found {
+		t.Fatalf("company name band not found")
+	}
+}
+
+func TestContactDetails(t *testing.T) {
+	doc, _ := New(Invoice, &Options{Secondary: &Options{TextPhoneTitle: "Téléphone"}})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{
+		Name:               "Acme Corp",
+		Address:            &Address{Address: "1 Rue de la Paix", PostalCode: "75001", City: "Paris"},
+		VATID:              "FR12345678901",
+		RegistrationID:     "123 456 789 00010",
+		RegistrationScheme: "SIRET",
+		LegalForm:          "SAS",
+		ShareCapital:       "10 000 €",
+		Email:              "billing@acme.example",
+		Phone:              "+33 1 23 45 67 89",
+		Website:            "acme.example",
+		AddtionnalInfo:     []string{"Member of an approved association"},
+	})
+	doc.SetCustomer(&Contact{Name: "Client Inc", ContactPerson: "John Doe", RegistrationID: "HRB 12345"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_contact_details.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if !bytes.Contains(out, utf16("SIRET: 123 456 789 00010")) {
+		t.Fatalf("PDF does not contain the registration number")
+	}
+
+	txt, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text: %v", err)
+	}
+	want := strings.Join([]string{
+		"SAS, Share capital: 10 000 €",
+		"SIRET: 123 456 789 00010",
+		"VAT: FR12345678901",
+		"Phone / Téléphone: +33 1 23 45 67 89",
+		"Email: billing@acme.example",
+		"acme.example",
+		"Member of an approved association",
+	}, "\n")
+	if !strings.Contains(txt, want) {
+		t.Fatalf("unexpected company details:\n%s", txt)
+	}
+	for _, line := range []string{"Contact: John Doe", "Registration no.: HRB 12345"} {
+		if !strings.Contains(txt, line) {
+			t.Fatalf("customer details miss %q:\n%s", line, txt)
+		}
+	}
+
+	doc.Company.Email = "billing"
+	var verr *ValidationError
+	if err := doc.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error for the email, got %v", err)
+	}
+}
+
+func TestPayment(t *testing.T) {
+	doc, _ := New(Invoice, &Options{})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.SetPaymentTerm("30 days")
+	doc.SetPayment(&Payment{
+		Means:         PaymentMeansSEPACreditTransfer,
+		MeansText:     "Bank transfer",
+		IBAN:          "fr7630006000011234567890189",
+		BIC:           "AGRIFRPP",
+		AccountHolder: "Acme Corp",
+		BankName:      "Crédit Agricole",
+		Reference:     "RF18 5390 0754 7034",
+	})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := os.WriteFile("../out/invoice_payment.pdf", out, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for _, text := range []string{"Payment details", "FR76 3000 6000 0112 3456 7890 189", "RF18 5390 0754 7034"} {
+		if !bytes.Contains(out, utf16(text)) {
+			t.Fatalf("PDF does not contain %q", text)
+		}
+	}
+
+	md, err := doc.Markdown()
+	if err != nil {
+		t.Fatalf("Markdown: %v", err)
+	}
+	if !strings.Contains(md, "**Payment details**  \nMethod: Bank transfer  \nAccount holder: Acme Corp") {
+		t.Fatalf("unexpected payment block:\n%s", md)
+	}
+
+	// The IBAN checksum and the BIC format are validated
+	doc.Payment.IBAN = "FR7630006000011234567890180"
+	doc.Payment.BIC = "AGRI"
+	var verr *ValidationError
+	if err := doc.Validate(); !errors.As(err, &verr) || len(verr.Errors) != 2 {
+		t.Fatalf("expected IBAN and BIC errors, got %v", err)
+	}
+}
+
+func TestHeaderFooterImages(t *testing.T) {
+	images := make([][]byte, 2)
+	for i, size := range []image.Rectangle{image.Rect(0, 0, 300, 50), image.Rect(0, 0, 200, 100)} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewRGBA(size)); err != nil {
+			t.Fatalf("png.Encode: %v", err)
+		}
+		images[i] = buf.Bytes()
+	}
+
+	doc, _ := New(Invoice, &Options{})
+	doc.OnPdfInit(func(pdf *fpdf.Fpdf) {
+		pdf.SetCompression(false)
+	})
+	doc.SetRef("INV-001")
+	doc.SetCompany(&Contact{Name: "Acme Corp"})
+	doc.SetCustomer(&Contact{Name: "Client Inc"})
+	doc.AppendItem(&Item{Name: "Service", UnitCost: "10", Quantity: "1"})
+	doc.SetHeader(&HeaderFooter{Image: images[0], Pagination: true})
+	doc.SetFooter(&HeaderFooter{Image: images[1], ImageHeight: 20, Pagination: true})
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	for _, size := range []string{"/Width 300", "/Width 200"} {
+		if !bytes.Contains(out, []byte(size)) {
+			t.Fatalf("PDF has no %s image", size)
+		}
+	}
+
+	// Pagination is drawn outside of the image bands
+	st := doc.style()
+	pageWidth, pageHeight := doc.pdf.GetPageSize()
+	if y := doc.headerPaginationY(doc.Header, pageWidth); y < st.HeaderMarginTop+10 {
+		t.Fatalf("header pagination at %.2f overlaps the image ending at %.2f", y, st.HeaderMarginTop+10)
+	}
+	imageTop := doc.footerTextY(pageHeight) - 1 - 20
+	if y := doc.footerPaginationY(doc.Footer, pageWidth, pageHeight); y+5 > imageTop {
+		t.Fatalf("footer pagination at %.2f overlaps the image starting at %.2f", y, imageTop)
+	}
+}
@@ -1,120 +1,337 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
-	"github.com/creasty/defaults"
 	"codeberg.org/go-pdf/fpdf"
+	"github.com/creasty/defaults"
 )
 
+// paginationMargin is the space between the page edges and the pagination
+const paginationMargin float64 = 5
+
+// ErrStalePdf is returned when rendering a document whose header or footer
+// ApplyFunc was given a PDF other than the one being rendered
+var ErrStalePdf = errors.New("ApplyFunc was given the PDF of an earlier render: use Func and its PageContext.Pdf, or call Document.Pdf in the function")
+
 // HeaderFooter define header or footer informations on document
 type HeaderFooter struct {
 	UseCustomFunc bool    `json:"-"`
 	Text          string  `json:"text,omitempty"`
 	FontSize      float64 `json:"font_size,omitempty" default:"7"`
-	Pagination    bool    `json:"pagination,omitempty"`
+
+	// Pagination draws PaginationFormat, where {page} is the page number and
+	// {total} the page count of the file, appendices included.
+	// PaginationAlign is AlignLeft, AlignCenter or AlignRight (default)
+	// between the page edges, mirrored in right to left documents.
+	Pagination       bool   `json:"pagination,omitempty"`
+	PaginationFormat string `json:"pagination_format,omitempty" default:"Page {page}/{total}"`
+	PaginationAlign  string `json:"pagination_align,omitempty" default:"R" validate:"omitempty,oneof=L C R"`
+
+	// Image is a PNG, JPEG, GIF or SVG image drawn ImageHeight mm high,
+	// between the margins, above Text. ImageAlign is AlignLeft (default),
+	// AlignCenter or AlignRight.
+	Image       []byte  `json:"image,omitempty"`
+	ImageHeight float64 `json:"image_height,omitempty" default:"10" validate:"gte=0"`
+	ImageAlign  string  `json:"image_align,omitempty" validate:"omitempty,oneof=L C R"`
+
+	// FirstPage replaces the header or footer on the first page, e.g. to
+	// leave the first page without header
+	FirstPage *HeaderFooter `json:"first_page,omitempty"`
+
+	// Func is called on every page, after Text, Image and pagination are
+	// drawn, to draw anything else with ctx.Pdf. Position and margins are
+	// restored after it. Documents with a Func are rendered twice to know
+	// their page count: Func is called with a zero Total during the first
+	// render, which must draw the same pages.
+	Func func(ctx *PageContext) `json:"-"`
+
+	customFunc fnc
+	customPdf  *fpdf.Fpdf
+}
+
+// PageContext describes the page a header or footer is drawn on
+type PageContext struct {
+	Document *Document
+	Pdf      *fpdf.Fpdf
+
+	// Page is the page number and Total the page count of the file,
+	// appendices included. First and Last report the first and last pages.
+	Page, Total int
+	First, Last bool
 }
 
 type fnc func()
 
-// ApplyFunc allow user to apply custom func
+// ApplyFunc allow user to apply custom func, drawn on every page when
+// UseCustomFunc is true. The func is kept so that it is applied again each
+// time the document is rendered.
+//
+// pdf is the PDF fn draws into when it captured one, nil when fn calls
+// Document.Pdf to get the PDF being drawn. Each render draws into a new
+// *fpdf.Fpdf: rendering fails with ErrStalePdf when pdf is not the PDF being
+// rendered, rather than let fn draw into a stale one.
+//
+// Deprecated: use Func, which receives the page context
 func (hf *HeaderFooter) ApplyFunc(pdf *fpdf.Fpdf, fn fnc) {
-	pdf.SetHeaderFunc(fn)
+	hf.customFunc = fn
+	hf.customPdf = pdf
+}
+
+// checkCustomPdf returns ErrStalePdf when the PDF given to ApplyFunc, for hf
+// or its first page variant, is not the PDF of doc
+func (hf *HeaderFooter) checkCustomPdf(doc *Document) error {
+	for _, hf := range []*HeaderFooter{hf, hf.FirstPage} {
+		if hf != nil && hf.UseCustomFunc && hf.customPdf != nil && hf.customPdf != doc.pdf {
+			return ErrStalePdf
+		}
+	}
+	return nil
+}
+
+// setDefaults sets the default values of hf and of its first page variant
+func (hf *HeaderFooter) setDefaults() error {
+	if err := defaults.Set(hf); err != nil {
+		return err
+	}
+	if hf.FirstPage != nil {
+		return defaults.Set(hf.FirstPage)
+	}
+	return nil
+}
+
+// forPage returns the header or footer drawn on page
+func (hf *HeaderFooter) forPage(page int) *HeaderFooter {
+	if page == 1 && hf.FirstPage != nil {
+		return hf.FirstPage
+	}
+	return hf
+}
+
+// hasFunc reports whether a Func of hf needs the page context
+func (hf *HeaderFooter) hasFunc() bool {
+	return hf != nil && (hf.Func != nil || (hf.FirstPage != nil && hf.FirstPage.Func != nil))
+}
+
+// pageContext returns the context of the current page
+func (doc *Document) pageContext() *PageContext {
+	page := doc.pdf.PageNo()
+	return &PageContext{
+		Document: doc,
+		Pdf:      doc.pdf,
+		Page:     page,
+		Total:    doc.pageTotal,
+		First:    page == 1,
+		Last:     page == doc.pageTotal,
+	}
+}
+
+// beginPage moves below the letterhead area of the page and draws what lies
+// under the content of every page: the watermark, unless it is drawn over
+// the content, and the header logos
+func (doc *Document) beginPage() {
+	doc.moveBelowLetterhead()
+	doc.appendWatermark(false)
+	doc.appendHeaderLogos()
+}
+
+// endPage draws what lies over the content of every page
+func (doc *Document) endPage() {
+	doc.appendWatermark(true)
+}
+
+// appendPagination draws the pagination of hf at y for page
+func (doc *Document) appendPagination(hf *HeaderFooter, y float64, page int) {
+	// {nb} is replaced with the page count of the PDF, unless merged
+	// appendices add pages to it (see appendixPages)
+	if doc.appendixPages == 0 {
+		doc.pdf.AliasNbPages("")
+	}
+
+	text := strings.NewReplacer("{page}", strconv.Itoa(page), "{total}", "{nb}").Replace(hf.PaginationFormat)
+	pageWidth, _ := doc.pdf.GetPageSize()
+	doc.pdf.SetY(y)
+	doc.pdf.SetX(paginationMargin)
+	doc.pdf.CellFormat(
+		pageWidth-2*paginationMargin,
+		5,
+		doc.Options.UnicodeTranslateFunc(text),
+		"0",
+		0,
+		doc.mirrorAlign(hf.PaginationAlign),
+		false,
+		0,
+		"",
+	)
+}
+
+// appendHeaderFooterImage draws the image of hf at y and returns its height
+func (doc *Document) appendHeaderFooterImage(hf *HeaderFooter, y float64) float64 {
+	svg, ratio := logoImage(hf.Image)
+	if ratio == 0 || hf.ImageHeight == 0 {
+		return 0
+	}
+
+	st := doc.style()
+	pageWidth, _ := doc.pdf.GetPageSize()
+	w, h := fitLogo(ratio, pageWidth-2*st.Margin, hf.ImageHeight)
+	x := doc.alignLogo(st.Margin, pageWidth-2*st.Margin, w, hf.ImageAlign)
+	if !doc.drawImage(hf.Image, svg, x, y, w, h) {
+		return 0
+	}
+	return h
+}
+
+// headerFooterImageHeight returns the height of the image of hf on a page
+// pageWidth wide, zero without image
+func (doc *Document) headerFooterImageHeight(hf *HeaderFooter, pageWidth float64) float64 {
+	_, ratio := logoImage(hf.Image)
+	if ratio == 0 || hf.ImageHeight == 0 {
+		return 0
+	}
+	_, h := fitLogo(ratio, pageWidth-2*doc.style().Margin, hf.ImageHeight)
+	return h
+}
+
+// headerPaginationY returns the position of the header pagination, below
+// the header image like the header text
+func (doc *Document) headerPaginationY(hf *HeaderFooter, pageWidth float64) float64 {
+	y := doc.style().HeaderMarginTop + 8
+	if h := doc.headerFooterImageHeight(hf, pageWidth); h > 0 {
+		y += h + 1
+	}
+	return y
+}
+
+// footerTextY returns the position of the footer text on a page pageHeight
+// high
+func (doc *Document) footerTextY(pageHeight float64) float64 {
+	return pageHeight - 10 - doc.style().HeaderMarginTop
+}
+
+// footerPaginationY returns the position of the footer pagination, above the
+// footer image drawn above the footer text
+func (doc *Document) footerPaginationY(hf *HeaderFooter, pageWidth, pageHeight float64) float64 {
+	y := doc.footerTextY(pageHeight) - 8
+	if doc.headerFooterImageHeight(hf, pageWidth) > 0 {
+		y -= hf.ImageHeight + 1
+	}
+	return y
 }
 
 // applyHeader apply header to document
 func (hf *HeaderFooter) applyHeader(doc *Document) error {
-	if err := defaults.Set(hf); err != nil {
+	if err := hf.setDefaults(); err != nil {
 		return err
 	}
+	if err := hf.checkCustomPdf(doc); err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+
+	doc.pdf.SetHeaderFunc(func() {
+		doc.beginPage()
 
-	if !hf.UseCustomFunc {
-		doc.pdf.SetHeaderFunc(func() {
-			currentY := doc.pdf.GetY()
-			currentX := doc.pdf.GetX()
-
-			doc.pdf.SetTopMargin(HeaderMarginTop)
-			doc.pdf.SetY(HeaderMarginTop)
-
-			doc.pdf.SetLeftMargin(BaseMargin)
-			doc.pdf.SetRightMargin(BaseMargin)
-
-			// Parse Text as html (simple)
-			doc.pdf.SetFont(doc.Options.Font, "", hf.FontSize)
-			_, lineHt := doc.pdf.GetFontSize()
-			html := doc.pdf.HTMLBasicNew()
-			html.Write(lineHt, doc.encodeString(hf.Text))
-
-			// Apply pagination
-			if !hf.Pagination {
-				doc.pdf.AliasNbPages("") // Will replace {nb} with total page count
-				doc.pdf.SetY(HeaderMarginTop + 8)
-				doc.pdf.SetX(195)
-				doc.pdf.CellFormat(
-					10,
-					5,
-					doc.encodeString(fmt.Sprintf("Page %d/{nb}", doc.pdf.PageNo())),
-					"0",
-					0,
-					"R",
-					false,
-					0,
-					"",
-				)
+		hf := hf.forPage(doc.pdf.PageNo())
+		if hf.UseCustomFunc {
+			if hf.customFunc != nil {
+				hf.customFunc()
 			}
+			return
+		}
 
-			doc.pdf.SetY(currentY)
-			doc.pdf.SetX(currentX)
-			doc.pdf.SetMargins(BaseMargin, BaseMarginTop, BaseMargin)
-		})
-	}
+		currentY := doc.pdf.GetY()
+		currentX := doc.pdf.GetX()
+
+		doc.pdf.SetTopMargin(doc.style().HeaderMarginTop)
+		doc.pdf.SetY(doc.style().HeaderMarginTop)
+
+		doc.pdf.SetLeftMargin(doc.style().Margin)
+		doc.pdf.SetRightMargin(doc.style().Margin)
+
+		// Image above the text
+		if h := doc.appendHeaderFooterImage(hf, doc.style().HeaderMarginTop); h > 0 {
+			doc.pdf.SetY(doc.style().HeaderMarginTop + h + 1)
+		}
+
+		// Parse Text as html (simple)
+		doc.pdf.SetFont(doc.Options.Font, "", hf.FontSize)
+		_, lineHt := doc.pdf.GetFontSize()
+		doc.writeHTML(lineHt, hf.Text)
+
+		// Apply pagination
+		if hf.Pagination {
+			pageWidth, _ := doc.pdf.GetPageSize()
+			doc.appendPagination(hf, doc.headerPaginationY(hf, pageWidth), doc.pdf.PageNo())
+		}
+
+		if hf.Func != nil {
+			hf.Func(doc.pageContext())
+		}
+
+		doc.pdf.SetY(currentY)
+		doc.pdf.SetX(currentX)
+		doc.pdf.SetMargins(doc.style().Margin, doc.style().MarginTop, doc.style().Margin)
+	})
 
 	return nil
 }
 
 // applyFooter apply footer to document
 func (hf *HeaderFooter) applyFooter(doc *Document) error {
-	if err := defaults.Set(hf); err != nil {
+	if err := hf.setDefaults(); err != nil {
 		return err
 	}
+	if err := hf.checkCustomPdf(doc); err != nil {
+		return fmt.Errorf("footer: %w", err)
+	}
 
-	if !hf.UseCustomFunc {
-		doc.pdf.SetFooterFunc(func() {
-			currentY := doc.pdf.GetY()
-			currentX := doc.pdf.GetX()
-
-			doc.pdf.SetTopMargin(HeaderMarginTop)
-			doc.pdf.SetY(287 - HeaderMarginTop)
-
-			// Parse Text as html (simple)
-			doc.pdf.SetFont(doc.Options.Font, "", hf.FontSize)
-			_, lineHt := doc.pdf.GetFontSize()
-			html := doc.pdf.HTMLBasicNew()
-			html.Write(lineHt, doc.encodeString(hf.Text))
-
-			// Apply pagination
-			if hf.Pagination {
-				doc.pdf.AliasNbPages("") // Will replace {nb} with total page count
-				doc.pdf.SetY(287 - HeaderMarginTop - 8)
-				doc.pdf.SetX(195)
-				doc.pdf.CellFormat(
-					10,
-					5,
-					doc.encodeString(fmt.Sprintf("Page %d/{nb}", doc.pdf.PageNo())),
-					"0",
-					0,
-					"R",
-					false,
-					0,
-					"",
-				)
+	doc.pdf.SetFooterFunc(func() {
+		hf := hf.forPage(doc.pdf.PageNo())
+		if hf.UseCustomFunc {
+			if hf.customFunc != nil {
+				hf.customFunc()
 			}
+			doc.endPage()
+			return
+		}
 
-			doc.pdf.SetY(currentY)
-			doc.pdf.SetX(currentX)
-			doc.pdf.SetMargins(BaseMargin, BaseMarginTop, BaseMargin)
-		})
-	}
+		currentY := doc.pdf.GetY()
+		currentX := doc.pdf.GetX()
+		pageWidth, pageHeight := doc.pdf.GetPageSize()
+		textY := doc.footerTextY(pageHeight)
+
+		doc.pdf.SetTopMargin(doc.style().HeaderMarginTop)
+
+		// Image above the text
+		if len(hf.Image) > 0 {
+			doc.appendHeaderFooterImage(hf, textY-1-hf.ImageHeight)
+		}
+
+		doc.pdf.SetY(textY)
+
+		// Parse Text as html (simple)
+		doc.pdf.SetFont(doc.Options.Font, "", hf.FontSize)
+		_, lineHt := doc.pdf.GetFontSize()
+		doc.writeHTML(lineHt, hf.Text)
+
+		// Apply pagination
+		if hf.Pagination {
+			doc.appendPagination(hf, doc.footerPaginationY(hf, pageWidth, pageHeight), doc.pdf.PageNo())
+		}
+
+		if hf.Func != nil {
+			hf.Func(doc.pageContext())
+		}
+
+		doc.pdf.SetY(currentY)
+		doc.pdf.SetX(currentX)
+		doc.pdf.SetMargins(doc.style().Margin, doc.style().MarginTop, doc.style().Margin)
+
+		doc.endPage()
+	})
 
 	return nil
 }
@@ -0,0 +1,251 @@
+package generator
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// HTML returns the document as a standalone HTML page with inline CSS, for
+// email bodies and previews. It has the labels, currency formatting and
+// totals of the PDF; logos are embedded as data URIs.
+func (doc *Document) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := doc.RenderHTML(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderHTML writes the document as a standalone HTML page to w, see HTML
+func (doc *Document) RenderHTML(w io.Writer) error {
+	v, err := doc.view()
+	if err != nil {
+		return err
+	}
+
+	opts := doc.Options
+	data := &htmlData{
+		documentView: v,
+		Ref:          doc.Ref,
+		Text:         cssColor(opts.BaseTextColor),
+		GreyText:     cssColor(opts.GreyTextColor),
+		GreyBg:       cssColor(opts.GreyBgColor),
+		DarkBg:       cssColor(opts.DarkBgColor),
+		CompanyLogo:  logoDataURI(doc.Company.Logo),
+		CustomerLogo: logoDataURI(v.Customer.Logo),
+	}
+
+	tableWidth := 0.0
+	for _, col := range v.Columns {
+		tableWidth += col.W
+	}
+	for _, col := range v.Columns {
+		hc := htmlColumn{Label: col.Label, Align: cssAlign(col.Align)}
+		if tableWidth > 0 {
+			hc.Width = fmt.Sprintf("%.1f%%", col.W/tableWidth*100)
+		}
+		if isBilingualLabel(col.Label, col.SecondaryLabel) {
+			hc.Label = doc.label(col.Label, col.SecondaryLabel)
+		}
+		data.Headers = append(data.Headers, hc)
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// htmlData is the data of htmlTemplate
+type htmlData struct {
+	*documentView
+
+	Ref                            string
+	Text, GreyText, GreyBg, DarkBg string
+	CompanyLogo, CustomerLogo      template.URL
+	Headers                        []htmlColumn
+}
+
+// htmlContact is a contact of htmlTemplate
+type htmlContact struct {
+	contactView
+
+	// Logo shadows the raw logo of contactView
+	Logo template.URL
+	Bg   string
+}
+
+// htmlColumn is an items table column of htmlTemplate
+type htmlColumn struct {
+	Label, Align, Width string
+}
+
+// cssColor formats an RGB option color as #rrggbb
+func cssColor(rgb []int) string {
+	return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+}
+
+// cssAlign converts a column alignment to a CSS text-align value
+func cssAlign(align string) string {
+	switch align {
+	case AlignCenter:
+		return "center"
+	case AlignRight:
+		return "right"
+	default:
+		return "left"
+	}
+}
+
+// logoDataURI returns logo as a data URI, empty when it is not an image
+func logoDataURI(logo []byte) template.URL {
+	if len(logo) == 0 {
+		return ""
+	}
+	if isSVG(logo) {
+		// #nosec G203 -- a base64 encoded image, not markup
+		return template.URL("data:image/svg+xml;base64," + b64.StdEncoding.EncodeToString(logo))
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(logo))
+	if err != nil {
+		return ""
+	}
+	// #nosec G203 -- a base64 encoded image of a known format
+	return template.URL("data:image/" + format + ";base64," + b64.StdEncoding.EncodeToString(logo))
+}
+
+// basicHTMLTag matches the tags the PDF notes support
+var basicHTMLTag = regexp.MustCompile(`(?i)<(/?)(b|i|u|br)\s*/?>`)
+
+// basicHTML escapes s except for its bold, italic, underline and line break
+// tags, like the PDF renders notes and contact additional info
+func basicHTML(s string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range basicHTMLTag.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(template.HTMLEscapeString(s[last:m[0]]))
+		b.WriteString("<" + s[m[2]:m[3]] + strings.ToLower(s[m[4]:m[5]]) + ">")
+		last = m[1]
+	}
+	b.WriteString(template.HTMLEscapeString(s[last:]))
+
+	// #nosec G203 -- only b, i, u and br tags are kept, the rest is escaped
+	return template.HTML(strings.ReplaceAll(b.String(), "\n", "<br>"))
+}
+
+var htmlTemplate = template.Must(template.New("document").Funcs(template.FuncMap{
+	"basicHTML": basicHTML,
+	"contactData": func(c contactView, logo template.URL, bg string) *htmlContact {
+		return &htmlContact{contactView: c, Logo: logo, Bg: bg}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} {{.Ref}}</title>
+</head>
+<body style="margin:0;padding:0;background-color:#ffffff;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="max-width:800px;margin:0 auto;font-family:Roboto,Helvetica,Arial,sans-serif;font-size:13px;line-height:1.4;color:{{.Text}};">
+<tr>
+<td style="padding:24px;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0">
+<tr>
+<td width="50%" valign="top" style="padding-right:12px;">
+{{- template "contact" (contactData .Company .CompanyLogo .GreyBg)}}
+</td>
+<td width="50%" valign="top" style="padding-left:12px;">
+<div style="background-color:{{.DarkBg}};padding:8px;text-align:center;font-size:20px;">{{.Title}}</div>
+{{- range .Metas}}
+<div style="text-align:right;font-size:11px;">{{.}}</div>
+{{- end}}
+<div style="height:12px;"></div>
+{{- template "contact" (contactData .Customer .CustomerLogo .GreyBg)}}
+{{- with .OtherParty}}
+<div style="margin-top:12px;font-size:11px;font-weight:bold;color:{{$.GreyText}};">{{.Title}}</div>
+{{- template "contact" (contactData . "" $.GreyBg)}}
+{{- end}}
+</td>
+</tr>
+</table>
+{{- if .Description}}
+<p style="margin:24px 0 0 0;padding-bottom:4px;border-bottom:1px solid {{.Text}};font-size:14px;">{{.Description}}</p>
+{{- end}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin-top:24px;border-collapse:collapse;font-size:11px;">
+<tr style="background-color:{{.GreyBg}};">
+{{- range .Headers}}
+<th{{if .Width}} width="{{.Width}}"{{end}} style="padding:6px 4px;text-align:{{.Align}};font-weight:bold;">{{.Label}}</th>
+{{- end}}
+</tr>
+{{- $cols := .Headers}}{{$grey := .GreyText}}{{$greyBg := .GreyBg}}
+{{- range .Rows}}
+<tr>
+{{- range $i, $cell := .}}
+<td valign="middle" style="padding:8px 4px;border-bottom:1px solid {{$greyBg}};text-align:{{(index $cols $i).Align}};">{{$cell.Text}}{{if $cell.Detail}}<div style="font-size:10px;color:{{$grey}};">{{$cell.Detail}}</div>{{end}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</table>
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="margin-top:24px;">
+<tr>
+<td valign="top" style="padding-right:24px;font-size:12px;">{{if .Notes}}{{basicHTML .Notes}}{{end}}</td>
+<td width="320" valign="top">
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="border-collapse:collapse;font-size:14px;">
+{{- $darkBg := .DarkBg}}
+{{- range .Totals}}
+{{- if .Sub}}
+<tr style="background-color:{{$greyBg}};font-size:11px;color:{{$grey}};">
+<td style="padding:4px 8px;text-align:right;">{{.Label}}</td>
+<td style="padding:4px 8px;">{{.Amount}}</td>
+</tr>
+{{- else}}
+<tr>
+<td width="50%" style="background-color:{{$darkBg}};padding:10px 8px;text-align:right;">{{.Label}}{{if .Detail}}<div style="font-size:11px;color:{{$grey}};">{{.Detail}}</div>{{end}}</td>
+<td width="50%" style="background-color:{{$greyBg}};padding:10px 8px;{{if .Grand}}font-weight:bold;{{end}}">{{.Amount}}</td>
+</tr>
+{{- end}}
+{{- end}}
+</table>
+{{- if .InWords}}
+<div style="margin-top:6px;text-align:right;font-size:10px;color:{{.GreyText}};">{{.InWords}}</div>
+{{- end}}
+{{- if .PaymentTerm}}
+<div style="margin-top:12px;text-align:right;font-weight:bold;font-size:14px;">{{.PaymentTerm}}</div>
+{{- end}}
+{{- if .Payment}}
+<div style="margin-top:12px;background-color:{{.GreyBg}};padding:6px 8px;font-weight:bold;">{{.PaymentTitle}}</div>
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0">
+{{- range .Payment}}
+<tr>
+<td width="35%" style="padding:2px 8px;font-size:11px;color:{{$grey}};">{{index . 0}}</td>
+<td style="padding:2px 8px;">{{index . 1}}</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+</td>
+</tr>
+</table>
+</td>
+</tr>
+</table>
+</body>
+</html>
+{{define "contact"}}
+{{- if .Logo}}
+<img src="{{.Logo}}" alt="{{.Name}}" style="display:block;height:80px;width:auto;margin-bottom:8px;">
+{{- end}}
+<div style="background-color:{{.Bg}};padding:6px 8px;font-weight:bold;font-size:14px;">{{.Name}}</div>
+{{- if .Lines}}
+<div style="background-color:{{.Bg}};margin-top:2px;padding:6px 8px;">{{range $i, $l := .Lines}}{{if $i}}<br>{{end}}{{$l}}{{end}}</div>
+{{- end}}
+{{- range .Details}}
+<div style="font-size:11px;">{{.}}</div>
+{{- end}}
+{{- range .Info}}
+<div style="font-size:11px;">{{basicHTML .}}</div>
+{{- end}}
+{{- end}}`))
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// assertGolden compares got with testdata/name, rewriting it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o750); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s differs from the golden file, run go test -update and review the diff\ngot:\n%s", name, got)
+	}
+}
+
+// goldenDoc returns a document covering every block of the renderers
+func goldenDoc(t *testing.T, opts *Options) *Document {
+	t.Helper()
+
+	doc, err := New(Invoice, opts)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	var logo bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 37, G: 99, B: 235, A: 255})
+	if err := png.Encode(&logo, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	doc.SetRef("INV-042")
+	doc.SetVersion("2")
+	doc.SetDate("01/03/2026")
+	doc.SetDescription("Website redesign & hosting")
+	doc.SetNotes("<b>Thank you</b> for your business.\nBank transfer only <script>alert(1)</script>")
+	doc.SetPaymentTerm("30/03/2026")
+	doc.SetCompany(&Contact{
+		Name:           "Acme Corp",
+		Logo:           logo.Bytes(),
+		Address:        &Address{Address: "1 Main St", PostalCode: "10001", City: "New York", Country: "USA"},
+		AddtionnalInfo: []string{"<i>VAT</i> US123456789"},
+	})
+	doc.SetCustomer(&Contact{
+		Name:    "Café Zoë",
+		Address: &Address{Address: "5 rue de la Paix", Address2: "2e étage", PostalCode: "75002", City: "Paris"},
+	})
+	doc.SetDefaultTax(&Tax{Percent: "20", Name: "VAT"})
+	doc.SetDiscount(&Discount{Percent: "10"})
+	doc.AppendItem(&Item{Name: "Design", Description: "Mockups and style guide", UnitCost: "1200", Quantity: "1"})
+	doc.AppendItem(&Item{Name: "Développement", UnitCost: "85.50", Quantity: "24", Discount: &Discount{Percent: "5"}})
+	doc.AppendItem(&Item{Name: "Hosting 日本", UnitCost: "19.99", Quantity: "12", Tax: &Tax{Amount: "12", Name: "Hosting tax"}})
+
+	return doc
+}
+
+func TestHTML(t *testing.T) {
+	doc := goldenDoc(t, &Options{AmountInWords: true, AmountInWordsCurrency: "USD", CurrencySymbol: "$"})
+
+	html, err := doc.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	assertGolden(t, "invoice.html", []byte(html))
+
+	if strings.Contains(html, "<script>") {
+		t.Fatal("notes must be escaped")
+	}
+	if !strings.Contains(html, doc.ac.FormatMoneyDecimal(doc.TotalWithTax())) {
+		t.Fatal("HTML must show the total with tax of the PDF")
+	}
+
+	bilingual := goldenDoc(t, &Options{Secondary: &Options{TextTypeInvoice: "FACTURE", TextItemsNameTitle: "Désignation"}})
+	html, err = bilingual.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	assertGolden(t, "invoice_bilingual.html", []byte(html))
+
+	doc.Ref = ""
+	if _, err := doc.HTML(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
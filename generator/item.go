@@ -17,33 +17,27 @@ type Item struct {
 	_quantity decimal.Decimal
 }
 
-// Prepare parses UnitCost and Quantity strings into decimal values
+// Prepare parses UnitCost and Quantity strings into decimal values.
+// It returns a *ValidationError listing every invalid field.
 func (i *Item) Prepare() error {
-	unitCost, err := decimal.NewFromString(i.UnitCost)
-	if err != nil {
-		return err
-	}
-	i._unitCost = unitCost
+	verr := &ValidationError{}
 
-	quantity, err := decimal.NewFromString(i.Quantity)
-	if err != nil {
-		return err
-	}
-	i._quantity = quantity
+	i._unitCost = verr.parseDecimal("unit_cost", i.UnitCost)
+	i._quantity = verr.parseDecimal("quantity", i.Quantity)
 
 	if i.Tax != nil {
 		if err := i.Tax.Prepare(); err != nil {
-			return err
+			verr.merge("tax", err)
 		}
 	}
 
 	if i.Discount != nil {
 		if err := i.Discount.Prepare(); err != nil {
-			return err
+			verr.merge("discount", err)
 		}
 	}
 
-	return nil
+	return verr.errOrNil()
 }
 
 // TotalWithoutTaxAndWithoutDiscount returns unit cost × quantity
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"bytes"
+
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Letterhead is an existing PDF drawn as the background of every page, the
+// generated content sitting on top of it. Backgrounds are added when the
+// document is written (Render, Bytes and batches): Build returns
+// ErrRenderRequired for documents with a letterhead.
+//
+// Backgrounds stay PDF/A compatible, so facturx can post-process the PDF.
+type Letterhead struct {
+	// FirstPage is a PDF whose first page is the background of the first
+	// page. NextPages, when set, is the background of the following pages.
+	FirstPage []byte `json:"first_page,omitempty" validate:"required"`
+	NextPages []byte `json:"next_pages,omitempty"`
+
+	// Top, Bottom, Left and Right are the areas of the letterhead, in mm, kept
+	// clear of content. They replace the style margins: the larger of Left and
+	// Right is used on both sides, and NextTop, when set, is the top area of
+	// the following pages. Zero keeps the style margin.
+	Top     float64 `json:"top,omitempty" validate:"gte=0"`
+	NextTop float64 `json:"next_top,omitempty" validate:"gte=0"`
+	Bottom  float64 `json:"bottom,omitempty" validate:"gte=0"`
+	Left    float64 `json:"left,omitempty" validate:"gte=0"`
+	Right   float64 `json:"right,omitempty" validate:"gte=0"`
+}
+
+// pdfcpuConf returns the configuration of the PDF post-processing
+func pdfcpuConf() *model.Configuration {
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	// Merged appendices get no outline entry
+	conf.CreateBookmarks = false
+	return conf
+}
+
+// validateLetterhead reports letterhead PDFs pdfcpu cannot read
+func (doc *Document) validateLetterhead(verr *ValidationError) {
+	l := doc.Options.Letterhead
+	if l == nil {
+		return
+	}
+	pdfs := []struct {
+		path string
+		pdf  []byte
+	}{{"first_page", l.FirstPage}, {"next_pages", l.NextPages}}
+	for _, p := range pdfs {
+		if len(p.pdf) == 0 {
+			continue
+		}
+		if _, err := pdfcpuapi.PageCount(bytes.NewReader(p.pdf), pdfcpuConf()); err != nil {
+			verr.add("options.letterhead."+p.path, ErrorCodeInvalid, "is not a PDF", err)
+		}
+	}
+}
+
+// style returns st with the margins set by the letterhead areas
+func (l *Letterhead) style(st Style) *Style {
+	if side := max(l.Left, l.Right); side > 0 {
+		st.Margin = side
+	}
+	if l.Top > 0 {
+		st.MarginTop = l.Top
+	}
+	if l.Bottom > 0 {
+		st.MarginBottom = l.Bottom
+	}
+	return &st
+}
+
+// moveBelowLetterhead moves the start of the pages after the first below
+// the top area of their letterhead
+func (doc *Document) moveBelowLetterhead() {
+	l := doc.Options.Letterhead
+	if l == nil || l.NextTop == 0 || doc.pdf.PageNo() == 1 {
+		return
+	}
+	doc.pdf.SetY(l.NextTop)
+}
+
+// underlay draws the letterhead pages as backgrounds of the pages of ctx
+func (l *Letterhead) underlay(ctx *model.Context) error {
+	var err error
+	all := types.IntSet{}
+	for nr := 1; nr <= ctx.PageCount; nr++ {
+		all[nr] = true
+	}
+	if len(l.NextPages) == 0 {
+		err = addBackground(ctx, l.FirstPage, all)
+	} else {
+		delete(all, 1)
+		err = addBackground(ctx, l.FirstPage, types.IntSet{1: true})
+		if err == nil && len(all) > 0 {
+			err = addBackground(ctx, l.NextPages, all)
+		}
+	}
+	return err
+}
+
+// addBackground draws the first page of background, at its original size
+// from the bottom left corner, under the content of pages
+func addBackground(ctx *model.Context, background []byte, pages types.IntSet) error {
+	wm, err := pdfcpuapi.PDFWatermarkForReadSeeker(bytes.NewReader(background), 1, "position:bl, scalefactor:1 abs, rotation:0", false, false, types.POINTS)
+	if err != nil {
+		return err
+	}
+	return pdfcpuapi.WatermarkContext(ctx, pages, wm)
+}
+
+// pdfaOptionalContent makes the optional content group pdfcpu puts
+// backgrounds in PDF/A-2 and PDF/A-3 compliant (ISO 19005-2, 6.9): its
+// default configuration must be named and must not have an AS entry.
+func pdfaOptionalContent(ctx *model.Context) error {
+	root, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+	o, ok := root.Find("OCProperties")
+	if !ok {
+		return nil
+	}
+	props, err := ctx.DereferenceDict(o)
+	if err != nil || props == nil {
+		return err
+	}
+	o, ok = props.Find("D")
+	if !ok {
+		return nil
+	}
+	config, err := ctx.DereferenceDict(o)
+	if err != nil || config == nil {
+		return err
+	}
+	config.Delete("AS")
+	config.Update("Name", types.StringLiteral("Default"))
+	return nil
+}
@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+// logoHeight is the default maximum height of contact logos
+const logoHeight float64 = 30
+
+// logoGap is the minimum space between a company logo and the right column
+const logoGap float64 = 5
+
+// LogoLayout sets the size and position of a contact logo
+type LogoLayout struct {
+	// MaxWidth and MaxHeight bound the logo, in mm, keeping its aspect ratio.
+	// They default to the contact block width and 30 mm. Company logos never
+	// reach the title column, whatever MaxWidth.
+	MaxWidth  float64 `json:"max_width,omitempty" validate:"gte=0"`
+	MaxHeight float64 `json:"max_height,omitempty" validate:"gte=0"`
+
+	// Align is AlignLeft (default), AlignCenter or AlignRight, in the contact
+	// block or, for header logos, between the page margins. Header logos of
+	// customers default to AlignRight.
+	Align string `json:"align,omitempty" validate:"omitempty,oneof=L C R"`
+
+	// InHeader draws the logo in the header of every page instead of the
+	// contact block. Its height is capped by the space above the top margin.
+	InHeader bool `json:"in_header,omitempty"`
+}
+
+// logoLayout returns the logo layout of c, the default one when not set
+func (c *Contact) logoLayout() *LogoLayout {
+	if c.LogoLayout == nil {
+		return &LogoLayout{}
+	}
+	return c.LogoLayout
+}
+
+// logoImage returns the parsed SVG logo, nil for raster logos, and the
+// width to height ratio of logo. The ratio is zero when logo cannot be
+// decoded.
+func logoImage(logo []byte) (*svgImage, float64) {
+	if isSVG(logo) {
+		img, err := parseSVG(logo)
+		if err != nil {
+			return nil, 0
+		}
+		return img, img.width / img.height
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(logo))
+	if err != nil || cfg.Height == 0 {
+		return nil, 0
+	}
+	return nil, float64(cfg.Width) / float64(cfg.Height)
+}
+
+// fitLogo returns the largest size of ratio fitting in maxW × maxH
+func fitLogo(ratio, maxW, maxH float64) (w, h float64) {
+	w, h = maxH*ratio, maxH
+	if w > maxW {
+		w, h = maxW, maxW/ratio
+	}
+	return w, h
+}
+
+// alignLogo returns the X of a logo of width w aligned in the box of width
+// boxW at x, alignments being mirrored in right to left documents
+func (doc *Document) alignLogo(x, boxW, w float64, align string) float64 {
+	if len(align) == 0 {
+		align = AlignLeft
+	}
+	switch doc.mirrorAlign(align) {
+	case AlignCenter:
+		return x + (boxW-w)/2
+	case AlignRight:
+		return x + boxW - w
+	default:
+		return x
+	}
+}
+
+// appendContactLogo draws the logo of c at the top of its contact block at
+// x, y, boxW wide, the logo being at most maxW wide, and returns its height
+func (doc *Document) appendContactLogo(c *Contact, x, y, boxW, maxW float64) float64 {
+	layout := c.logoLayout()
+	svg, ratio := logoImage(c.Logo)
+	if ratio == 0 {
+		return 0
+	}
+
+	maxH := logoHeight
+	if layout.MaxHeight > 0 {
+		maxH = layout.MaxHeight
+	}
+	if layout.MaxWidth > 0 {
+		maxW = min(maxW, layout.MaxWidth)
+	} else {
+		maxW = min(maxW, boxW)
+	}
+	w, h := fitLogo(ratio, maxW, maxH)
+
+	// Logos wider than the block overflow it towards the middle of the page
+	align := layout.Align
+	if w > boxW {
+		align = AlignLeft
+	}
+	x = doc.alignLogo(x, boxW, w, align)
+	if !doc.drawImage(c.Logo, svg, x, y, w, h) {
+		return 0
+	}
+	return h
+}
+
+// hasHeaderLogos reports whether a contact logo is drawn in the page header
+func (doc *Document) hasHeaderLogos() bool {
+	for _, c := range []*Contact{doc.Company, doc.Customer} {
+		if c != nil && c.Logo != nil && c.logoLayout().InHeader {
+			return true
+		}
+	}
+	return false
+}
+
+// appendHeaderLogos draws the contact logos laid out in the page header
+func (doc *Document) appendHeaderLogos() {
+	if !doc.hasHeaderLogos() {
+		return
+	}
+
+	st := doc.style()
+	x, y := doc.pdf.GetXY()
+	pageWidth, _ := doc.pdf.GetPageSize()
+	headerHeight := st.MarginTop - st.HeaderMarginTop - 2
+
+	for _, c := range []*Contact{doc.Company, doc.Customer} {
+		if c == nil || c.Logo == nil || !c.logoLayout().InHeader {
+			continue
+		}
+		layout := c.logoLayout()
+		svg, ratio := logoImage(c.Logo)
+		if ratio == 0 || headerHeight <= 0 {
+			continue
+		}
+
+		maxW, maxH := contactWidth, headerHeight
+		if layout.MaxWidth > 0 {
+			maxW = layout.MaxWidth
+		}
+		if layout.MaxHeight > 0 {
+			maxH = min(maxH, layout.MaxHeight)
+		}
+		w, h := fitLogo(ratio, min(maxW, pageWidth-2*st.Margin), maxH)
+
+		align := layout.Align
+		if len(align) == 0 && c == doc.Customer {
+			align = AlignRight
+		}
+		logoX := doc.alignLogo(st.Margin, pageWidth-2*st.Margin, w, align)
+		doc.drawImage(c.Logo, svg, logoX, st.HeaderMarginTop, w, h)
+	}
+
+	doc.pdf.SetXY(x, y)
+}
+
+// drawImage draws the image data in the w × h box at x, y: as vectors when
+// svg is not nil, from the render cache in batches. Raster images are
+// registered under their content hash, fpdf ignoring the data of a name
+// already registered. It reports whether the image was drawn.
+func (doc *Document) drawImage(data []byte, svg *svgImage, x, y, w, h float64) bool {
+	if svg != nil {
+		doc.drawSVG(svg, x, y, w, h)
+		return true
+	}
+
+	if tpl := doc.cachedLogo(data); tpl != nil {
+		doc.pdf.UseTemplateScaled(tpl, fpdf.PointType{X: x, Y: y}, fpdf.SizeType{Wd: w, Ht: h})
+		return true
+	}
+
+	sum := sha256.Sum256(data)
+	fileName := hex.EncodeToString(sum[:])
+	_, format, _ := image.DecodeConfig(bytes.NewReader(data))
+	opts := fpdf.ImageOptions{ImageType: format}
+	if doc.pdf.RegisterImageOptionsReader(fileName, opts, bytes.NewReader(data)) == nil {
+		return false
+	}
+	doc.pdf.ImageOptions(fileName, x, y, w, h, false, opts, 0, "")
+	return true
+}
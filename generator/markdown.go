@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Markdown returns the document as GitHub flavored Markdown, for chat
+// notifications and tickets. It has the labels, currency formatting and
+// totals of the PDF; the items and totals are tables whose source is
+// aligned like the text rendering.
+func (doc *Document) Markdown() (string, error) {
+	var buf bytes.Buffer
+	if err := doc.RenderMarkdown(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderMarkdown writes the document as Markdown to w, see Markdown
+func (doc *Document) RenderMarkdown(w io.Writer) error {
+	v, err := doc.view()
+	if err != nil {
+		return err
+	}
+
+	var b textBuilder
+	b.line("# " + markdownEscape(v.Title))
+	b.line("")
+	b.WriteString(markdownLines(v.Metas) + "\n")
+
+	for _, c := range v.contacts() {
+		lines := []string{"**" + markdownEscape(c.Name) + "**"}
+		if len(c.Title) > 0 {
+			lines = append([]string{"*" + markdownEscape(c.Title) + "*"}, lines...)
+		}
+		for _, l := range c.Lines {
+			lines = append(lines, markdownEscape(l))
+		}
+		for _, detail := range c.Details {
+			lines = append(lines, markdownEscape(detail))
+		}
+		for _, info := range c.Info {
+			lines = append(lines, markdownBasicHTML(info))
+		}
+		b.line("")
+		b.WriteString(strings.Join(lines, "  \n") + "\n")
+	}
+
+	if len(v.Description) > 0 {
+		b.line("")
+		b.line(markdownEscape(v.Description))
+	}
+
+	// Items table, details in parentheses after the cell text
+	header := make([]string, len(v.Columns))
+	aligns := make([]string, len(v.Columns))
+	for i, col := range v.Columns {
+		header[i] = markdownEscape(doc.label(col.Label, col.SecondaryLabel))
+		aligns[i] = col.Align
+	}
+	rows := [][]string{header}
+	for _, row := range v.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscape(cell.Text)
+			if len(cell.Detail) > 0 {
+				cells[i] += " (" + markdownEscape(cell.Detail) + ")"
+			}
+		}
+		rows = append(rows, cells)
+	}
+	b.line("")
+	writeMarkdownTable(&b, rows, aligns)
+
+	// Totals
+	totals := [][]string{{"", ""}}
+	for _, tl := range v.Totals {
+		label, amount := markdownEscape(tl.Label), markdownEscape(tl.Amount)
+		if len(tl.Detail) > 0 {
+			label += " (" + markdownEscape(tl.Detail) + ")"
+		}
+		if tl.Sub {
+			label = "_" + label + "_"
+		}
+		if tl.Grand {
+			label, amount = "**"+label+"**", "**"+amount+"**"
+		}
+		totals = append(totals, []string{label, amount})
+	}
+	b.line("")
+	writeMarkdownTable(&b, totals, []string{AlignRight, AlignRight})
+
+	if len(v.InWords) > 0 {
+		b.line("")
+		b.line("_" + markdownEscape(v.InWords) + "_")
+	}
+	if len(v.PaymentTerm) > 0 {
+		b.line("")
+		b.line("**" + markdownEscape(v.PaymentTerm) + "**")
+	}
+	if len(v.Payment) > 0 {
+		lines := []string{"**" + markdownEscape(v.PaymentTitle) + "**"}
+		for _, line := range v.Payment {
+			lines = append(lines, markdownEscape(line[0])+": "+markdownEscape(line[1]))
+		}
+		b.line("")
+		b.WriteString(strings.Join(lines, "  \n") + "\n")
+	}
+	if len(v.Notes) > 0 {
+		b.line("")
+		b.WriteString(markdownBasicHTML(v.Notes) + "\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownTable writes rows as a table whose first row is the header,
+// padding the cells so that the source is aligned too
+func writeMarkdownTable(b *textBuilder, rows [][]string, aligns []string) {
+	widths := columnWidths(rows)
+	for i := range widths {
+		// The delimiter row needs at least 3 dashes
+		widths[i] = max(widths[i], 3)
+	}
+
+	row := func(cells []string) {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			padded[i] = padCell(cell, widths[i], aligns[i])
+		}
+		b.line("| " + strings.Join(padded, " | ") + " |")
+	}
+
+	row(rows[0])
+	delimiters := make([]string, len(widths))
+	for i, width := range widths {
+		switch aligns[i] {
+		case AlignRight:
+			delimiters[i] = strings.Repeat("-", width-1) + ":"
+		case AlignCenter:
+			delimiters[i] = ":" + strings.Repeat("-", width-2) + ":"
+		default:
+			delimiters[i] = strings.Repeat("-", width)
+		}
+	}
+	b.line("| " + strings.Join(delimiters, " | ") + " |")
+	for _, cells := range rows[1:] {
+		row(cells)
+	}
+}
+
+// markdownLines joins escaped lines with hard line breaks
+func markdownLines(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = markdownEscape(l)
+	}
+	return strings.Join(escaped, "  \n")
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+// markdownEscape escapes the Markdown syntax of s, so that user text renders
+// as is
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownBasicHTML converts the bold, italic and line break tags of s to
+// Markdown and escapes the rest. Underline has no Markdown equivalent and is
+// dropped.
+func markdownBasicHTML(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range basicHTMLTag.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(markdownEscape(s[last:m[0]]))
+		switch strings.ToLower(s[m[4]:m[5]]) {
+		case "b":
+			b.WriteString("**")
+		case "i":
+			b.WriteString("_")
+		case "br":
+			b.WriteString("\n")
+		}
+		last = m[1]
+	}
+	b.WriteString(markdownEscape(s[last:]))
+	return strings.ReplaceAll(b.String(), "\n", "  \n")
+}
@@ -10,6 +10,13 @@ type Options struct {
 	CurrencyDecimal   string `default:"." json:"currency_decimal,omitempty"`
 	CurrencyThousand  string `default:" " json:"currency_thousand,omitempty"`
 
+	// AmountInWords renders the total with tax in words below the totals block,
+	// see Document.TotalInWords
+	AmountInWords         bool           `json:"amount_in_words,omitempty"`
+	AmountInWordsLanguage string         `default:"en" json:"amount_in_words_language,omitempty"`
+	AmountInWordsCurrency string         `default:"EUR" json:"amount_in_words_currency,omitempty"`
+	CurrencyWords         *CurrencyWords `json:"currency_words,omitempty"`
+
 	TextTypeInvoice      string `default:"INVOICE" json:"text_type_invoice,omitempty"`
 	TextTypeQuotation    string `default:"QUOTATION" json:"text_type_quotation,omitempty"`
 	TextTypeDeliveryNote string `default:"DELIVERY NOTE" json:"text_type_delivery_note,omitempty"`
@@ -18,6 +25,23 @@ type Options struct {
 	TextVersionTitle     string `default:"Version" json:"text_version_title,omitempty"`
 	TextDateTitle        string `default:"Date" json:"text_date_title,omitempty"`
 	TextPaymentTermTitle string `default:"Payment term" json:"text_payment_term_title,omitempty"`
+	TextShipToTitle      string `default:"Ship to" json:"text_ship_to_title,omitempty"`
+	TextBillToTitle      string `default:"Bill to" json:"text_bill_to_title,omitempty"`
+
+	TextContactPersonTitle  string `default:"Contact" json:"text_contact_person_title,omitempty"`
+	TextShareCapitalTitle   string `default:"Share capital" json:"text_share_capital_title,omitempty"`
+	TextRegistrationIDTitle string `default:"Registration no." json:"text_registration_id_title,omitempty"`
+	TextVATIDTitle          string `default:"VAT" json:"text_vat_id_title,omitempty"`
+	TextPhoneTitle          string `default:"Phone" json:"text_phone_title,omitempty"`
+	TextEmailTitle          string `default:"Email" json:"text_email_title,omitempty"`
+
+	TextPaymentTitle          string `default:"Payment details" json:"text_payment_title,omitempty"`
+	TextPaymentMeansTitle     string `default:"Method" json:"text_payment_means_title,omitempty"`
+	TextAccountHolderTitle    string `default:"Account holder" json:"text_account_holder_title,omitempty"`
+	TextBankNameTitle         string `default:"Bank" json:"text_bank_name_title,omitempty"`
+	TextIBANTitle             string `default:"IBAN" json:"text_iban_title,omitempty"`
+	TextBICTitle              string `default:"BIC" json:"text_bic_title,omitempty"`
+	TextPaymentReferenceTitle string `default:"Reference" json:"text_payment_reference_title,omitempty"`
 
 	TextItemsNameTitle     string `default:"Name" json:"text_items_name_title,omitempty"`
 	TextItemsUnitCostTitle string `default:"Unit price" json:"text_items_unit_cost_title,omitempty"`
@@ -27,19 +51,90 @@ type Options struct {
 	TextItemsDiscountTitle string `default:"Discount" json:"text_items_discount_title,omitempty"`
 	TextItemsTotalTTCTitle string `default:"Total" json:"text_items_total_ttc_title,omitempty"`
 
+	// CarriedForward draws the running subtotal of the items at the bottom of
+	// each page the items table continues from, and at the top of the next
+	CarriedForward     bool   `json:"carried_forward,omitempty"`
+	TextCarriedForward string `default:"Carried forward" json:"text_carried_forward,omitempty"`
+	TextBroughtForward string `default:"Brought forward" json:"text_brought_forward,omitempty"`
+
 	TextTotalTotal      string `default:"Total" json:"text_total_total,omitempty"`
 	TextTotalDiscounted string `default:"Total discounted" json:"text_total_discounted,omitempty"`
 	TextTotalTax        string `default:"Tax" json:"text_total_tax,omitempty"`
 	TextTotalTaxOther   string `default:"Other" json:"text_total_tax_other,omitempty"`
 	TextTotalWithTax    string `default:"Total with tax" json:"text_total_with_tax,omitempty"`
+	TextAmountInWords   string `default:"Amount in words:" json:"text_amount_in_words,omitempty"`
+
+	// Acceptance draws an acceptance block ("Bon pour accord") below the
+	// payment term of quotations, with the mention the customer writes and
+	// name, date and signature areas. AcceptanceFormFields makes the name and
+	// date fillable and adds a signature field, when the document is written
+	// (Render, Bytes and batches): Build returns ErrRenderRequired for them.
+	Acceptance           bool `json:"acceptance,omitempty"`
+	AcceptanceFormFields bool `json:"acceptance_form_fields,omitempty"`
+
+	TextAcceptanceTitle     string `default:"Approval" json:"text_acceptance_title,omitempty"`
+	TextAcceptanceMention   string `default:"Date and sign, preceded by the handwritten mention 'Approved and agreed'" json:"text_acceptance_mention,omitempty"`
+	TextAcceptanceName      string `default:"Name" json:"text_acceptance_name,omitempty"`
+	TextAcceptanceDate      string `default:"Date" json:"text_acceptance_date,omitempty"`
+	TextAcceptanceSignature string `default:"Signature" json:"text_acceptance_signature,omitempty"`
 
 	BaseTextColor []int `default:"[35,35,35]" json:"base_text_color,omitempty"`
 	GreyTextColor []int `default:"[82,82,82]" json:"grey_text_color,omitempty"`
 	GreyBgColor   []int `default:"[232,232,232]" json:"grey_bg_color,omitempty"`
 	DarkBgColor   []int `default:"[212,212,212]" json:"dark_bg_color,omitempty"`
 
-	Font     string `default:"Roboto"`
-	BoldFont string `default:"Roboto"`
+	// Font and BoldFont are the families of regular and bold texts. The
+	// default Roboto family has no italic style.
+	Font     string `default:"Roboto" json:"font,omitempty"`
+	BoldFont string `default:"Roboto" json:"bold_font,omitempty"`
+
+	// Fonts is a TrueType family with its four styles that New registers
+	// and uses as Font and BoldFont
+	Fonts *FontFamily `json:"fonts,omitempty"`
+
+	// FallbackFonts are tried in order for the characters Font and BoldFont
+	// have no glyph for (e.g. Chinese or Arabic names): text is drawn in runs,
+	// each in the first font that has its glyphs.
+	FallbackFonts []*FontFamily `json:"fallback_fonts,omitempty"`
+
+	// PageSize is one of the PageSize* constants. PageWidth and PageHeight,
+	// in mm, give the portrait dimensions of PageSizeCustom pages.
+	PageSize        string  `default:"A4" json:"page_size,omitempty" validate:"oneof=A3 A4 A5 Letter Legal Custom"`
+	PageWidth       float64 `json:"page_width,omitempty"`
+	PageHeight      float64 `json:"page_height,omitempty"`
+	PageOrientation string  `default:"P" json:"page_orientation,omitempty" validate:"oneof=P L"`
+
+	// Direction is DirectionLTR or DirectionRTL. Right to left documents
+	// mirror the layout and shape and reorder Arabic and Hebrew text; their
+	// fonts must have the glyphs (see the fonts/dejavu package).
+	Direction string `default:"ltr" json:"direction,omitempty" validate:"oneof=ltr rtl"`
+
+	// Style holds font sizes, margins and spacing
+	Style Style `json:"style,omitempty"`
+
+	// Letterhead is a PDF drawn as the background of every page when the
+	// document is written (Build returns ErrRenderRequired). Its areas
+	// replace the margins of Style.
+	Letterhead *Letterhead `json:"letterhead,omitempty"`
+
+	// Theme is the name of a built-in theme (see the Theme* constants).
+	// Document.SetTheme draws the document with a custom one.
+	Theme string `default:"classic" json:"theme,omitempty"`
+
+	// AccentColor is the color of the modern theme bands
+	AccentColor []int `default:"[37,99,235]" json:"accent_color,omitempty"`
+
+	// Columns of the items table, DefaultColumns() when empty
+	Columns []*Column `json:"columns,omitempty"`
+
+	// Secondary holds the labels of a second locale for bilingual documents
+	// ("Facture / Invoice"). Only its Text* fields are used.
+	Secondary *Options `json:"secondary,omitempty"`
+
+	// BilingualLayout is BilingualInline or BilingualStacked. Inline labels
+	// that do not fit their cell are stacked anyway.
+	BilingualLayout    string `default:"inline" json:"bilingual_layout,omitempty"`
+	BilingualSeparator string `default:" / " json:"bilingual_separator,omitempty"`
 
 	UnicodeTranslateFunc UnicodeTranslateFunc
 }
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// paymentLabelWidth is the width of the labels of the payment block
+const paymentLabelWidth float64 = 22
+
+// Payment means, UN/ECE 4461 codes
+const (
+	PaymentMeansCash               string = "10"
+	PaymentMeansCheque             string = "20"
+	PaymentMeansCreditTransfer     string = "30"
+	PaymentMeansCard               string = "48"
+	PaymentMeansDirectDebit        string = "49"
+	PaymentMeansSEPACreditTransfer string = "58"
+	PaymentMeansSEPADirectDebit    string = "59"
+)
+
+// Payment holds the instructions to pay the document, drawn below the totals
+type Payment struct {
+	// Means is the UN/ECE 4461 code of the payment means, e.g.
+	// PaymentMeansSEPACreditTransfer, used by e-invoices. MeansText is the
+	// payment method printed, e.g. "Bank transfer".
+	Means     string `json:"means,omitempty" validate:"omitempty,numeric,max=3"`
+	MeansText string `json:"means_text,omitempty"`
+
+	// IBAN is printed in groups of four characters, whatever its spacing
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty" validate:"omitempty,bic"`
+	AccountHolder string `json:"account_holder,omitempty"`
+	BankName      string `json:"bank_name,omitempty"`
+
+	// Reference is the remittance reference the payer must quote, e.g. a
+	// structured creditor reference (RF18 5390 0754 7034)
+	Reference string `json:"reference,omitempty"`
+}
+
+// CompactIBAN returns the IBAN without spaces, in upper case
+func (p *Payment) CompactIBAN() string {
+	return strings.ToUpper(strings.Join(strings.Fields(p.IBAN), ""))
+}
+
+// validIBAN reports whether iban, compact, has a valid ISO 13616 checksum
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// The country code and check digits move to the end, letters count as
+	// 10 to 35, and the number must be 1 modulo 97
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && n.Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validatePayment reports an IBAN with a wrong checksum
+func (doc *Document) validatePayment(verr *ValidationError) {
+	if doc.Payment == nil || len(doc.Payment.IBAN) == 0 {
+		return
+	}
+	if !validIBAN(doc.Payment.CompactIBAN()) {
+		verr.add("payment.iban", ErrorCodeInvalid, "is not a valid IBAN", nil)
+	}
+}
+
+// paymentLines returns the labels and values of the payment block
+func (doc *Document) paymentLines() [][2]string {
+	p := doc.Payment
+	if p == nil {
+		return nil
+	}
+
+	opts, secondary := doc.Options, doc.secondary()
+	iban := p.CompactIBAN()
+	var groups []string
+	for len(iban) > 4 {
+		groups, iban = append(groups, iban[:4]), iban[4:]
+	}
+	iban = strings.Join(append(groups, iban), " ")
+
+	var lines [][2]string
+	for _, line := range []struct {
+		title, secondaryTitle, value string
+	}{
+		{opts.TextPaymentMeansTitle, secondary.TextPaymentMeansTitle, p.MeansText},
+		{opts.TextAccountHolderTitle, secondary.TextAccountHolderTitle, p.AccountHolder},
+		{opts.TextBankNameTitle, secondary.TextBankNameTitle, p.BankName},
+		{opts.TextIBANTitle, secondary.TextIBANTitle, iban},
+		{opts.TextBICTitle, secondary.TextBICTitle, p.BIC},
+		{opts.TextPaymentReferenceTitle, secondary.TextPaymentReferenceTitle, p.Reference},
+	} {
+		if len(line.value) > 0 {
+			lines = append(lines, [2]string{doc.label(line.title, line.secondaryTitle), line.value})
+		}
+	}
+	return lines
+}
+
+// appendPayment draws the payment instructions below the totals, in the
+// right column
+func (doc *Document) appendPayment() {
+	lines := doc.paymentLines()
+	if len(lines) == 0 {
+		return
+	}
+
+	st := doc.style()
+	x := doc.rightColumnX()
+	secondary := doc.secondary()
+
+	// Title band
+	y := doc.pdf.GetY() + st.SectionSpacing
+	doc.fillRect(doc.Options.GreyBgColor, x, y, rightColumnWidth, 8)
+	doc.pdf.SetXY(doc.mirrorX(x+2, rightColumnWidth-4), y)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", st.BaseFontSize)
+	doc.labelCell(rightColumnWidth-4, 8, doc.Options.TextPaymentTitle, secondary.TextPaymentTitle, "L")
+	y += 9
+
+	for _, line := range lines {
+		doc.pdf.SetXY(doc.mirrorX(x+2, paymentLabelWidth), y)
+		doc.pdf.SetFont(doc.Options.Font, "", st.SmallFontSize)
+		doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+		doc.cellFormat(paymentLabelWidth, 5, line[0], "0", 0, doc.mirrorAlign("L"), false)
+		doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+
+		valueW := rightColumnWidth - paymentLabelWidth - 4
+		doc.pdf.SetXY(doc.mirrorX(x+2+paymentLabelWidth, valueW), y)
+		doc.pdf.SetFont(doc.Options.Font, "", st.BaseFontSize)
+		doc.multiCell(valueW, 5, line[1], "0", "L", false)
+		y = doc.pdf.GetY()
+	}
+
+	doc.pdf.SetY(y)
+}
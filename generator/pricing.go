@@ -24,32 +24,26 @@ type Discount struct {
 	_amount  decimal.Decimal
 }
 
-// Prepare parses and validates the discount fields
+// Prepare parses and validates the discount fields.
+// It returns a *ValidationError wrapping ErrInvalidDiscount when neither or
+// both fields are set: compare with errors.Is, not ==.
 func (d *Discount) Prepare() error {
-	if len(d.Percent) == 0 && len(d.Amount) == 0 {
-		return ErrInvalidDiscount
-	}
-	if len(d.Percent) > 0 && len(d.Amount) > 0 {
-		return ErrInvalidDiscount
+	verr := &ValidationError{}
+
+	if (len(d.Percent) == 0) == (len(d.Amount) == 0) {
+		verr.add("", ErrorCodeInvalidDiscount, "exactly one of percent or amount must be set", ErrInvalidDiscount)
+		return verr
 	}
 
 	if len(d.Percent) > 0 {
-		percent, err := decimal.NewFromString(d.Percent)
-		if err != nil {
-			return err
-		}
-		d._percent = percent
+		d._percent = verr.parseDecimal("percent", d.Percent)
 	}
 
 	if len(d.Amount) > 0 {
-		amount, err := decimal.NewFromString(d.Amount)
-		if err != nil {
-			return err
-		}
-		d._amount = amount
+		d._amount = verr.parseDecimal("amount", d.Amount)
 	}
 
-	return nil
+	return verr.errOrNil()
 }
 
 func (d *Discount) getDiscount() (string, decimal.Decimal) {
@@ -80,32 +74,26 @@ type Tax struct {
 	_amount  decimal.Decimal
 }
 
-// Prepare parses and validates the tax fields
+// Prepare parses and validates the tax fields.
+// It returns a *ValidationError wrapping ErrInvalidTax when neither or both
+// fields are set: compare with errors.Is, not ==.
 func (t *Tax) Prepare() error {
-	if len(t.Percent) == 0 && len(t.Amount) == 0 {
-		return ErrInvalidTax
-	}
-	if len(t.Percent) > 0 && len(t.Amount) > 0 {
-		return ErrInvalidTax
+	verr := &ValidationError{}
+
+	if (len(t.Percent) == 0) == (len(t.Amount) == 0) {
+		verr.add("", ErrorCodeInvalidTax, "exactly one of percent or amount must be set", ErrInvalidTax)
+		return verr
 	}
 
 	if len(t.Percent) > 0 {
-		percent, err := decimal.NewFromString(t.Percent)
-		if err != nil {
-			return err
-		}
-		t._percent = percent
+		t._percent = verr.parseDecimal("percent", t.Percent)
 	}
 
 	if len(t.Amount) > 0 {
-		amount, err := decimal.NewFromString(t.Amount)
-		if err != nil {
-			return err
-		}
-		t._amount = amount
+		t._amount = verr.parseDecimal("amount", t.Amount)
 	}
 
-	return nil
+	return verr.errOrNil()
 }
 
 func (t *Tax) getTax() (string, decimal.Decimal) {
@@ -0,0 +1,323 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Text directions of Options.Direction
+const (
+	DirectionLTR string = "ltr"
+	DirectionRTL string = "rtl"
+)
+
+// isRTL reports whether the document is laid out right to left
+func (doc *Document) isRTL() bool {
+	return doc.Options.Direction == DirectionRTL
+}
+
+// mirrorX returns the left X of a box of width w laid out at x. The layout
+// code places boxes left to right; right to left documents draw them
+// mirrored around the middle of the page.
+func (doc *Document) mirrorX(x, w float64) float64 {
+	if !doc.isRTL() {
+		return x
+	}
+	pageWidth, _ := doc.pdf.GetPageSize()
+	return pageWidth - x - w
+}
+
+// mirrorAlign swaps the left and right alignments of alignStr (e.g. "BR")
+// in right to left documents
+func (doc *Document) mirrorAlign(alignStr string) string {
+	if !doc.isRTL() {
+		return alignStr
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'L':
+			return 'R'
+		case 'R':
+			return 'L'
+		}
+		return r
+	}, alignStr)
+}
+
+// multiCell draws str like fpdf MultiCell. Right to left documents wrap the
+// logical text first and reorder each line for display, since reordering
+// before wrapping would put the end of a paragraph on its first line.
+// Documents with fallback fonts wrap it themselves too, measuring each font
+// run with its own font.
+func (doc *Document) multiCell(w, h float64, str, borderStr, alignStr string, fill bool) {
+	alignStr = doc.mirrorAlign(alignStr)
+	if !doc.isRTL() && doc.fonts == nil {
+		doc.pdf.MultiCell(w, h, doc.encodeString(str), borderStr, alignStr, fill)
+		return
+	}
+
+	lines := doc.splitText(str, w)
+	x := doc.pdf.GetX()
+	for i, line := range lines {
+		border := ""
+		if strings.Contains(borderStr, "B") && i == len(lines)-1 {
+			border = "B"
+		}
+		if doc.isRTL() {
+			line = visualOrder(line)
+		}
+		doc.pdf.SetX(x)
+		doc.drawCell(w, h, doc.Options.UnicodeTranslateFunc(line), border, 2, alignStr, fill)
+	}
+}
+
+// htmlTag matches the tags of basic HTML texts
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// writeHTML writes the basic HTML str (see fpdf HTMLBasicNew) between the
+// margins. fpdf cannot wrap right to left text, so right to left documents
+// draw it as plain text, right aligned or centered when it has a <center>
+// tag.
+func (doc *Document) writeHTML(lineHt float64, str string) {
+	if !doc.isRTL() {
+		doc.writeHTMLRuns(lineHt, doc.encodeString(str))
+		return
+	}
+
+	align := "L"
+	if strings.Contains(strings.ToLower(str), "<center>") {
+		align = "C"
+	}
+	text := htmlTag.ReplaceAllStringFunc(str, func(tag string) string {
+		if basicHTMLTag.MatchString(tag) && strings.Contains(strings.ToLower(tag), "br") {
+			return "\n"
+		}
+		return ""
+	})
+
+	pageWidth, _ := doc.pdf.GetPageSize()
+	left, _, right, _ := doc.pdf.GetMargins()
+	doc.pdf.SetX(left)
+	doc.multiCell(pageWidth-left-right, lineHt, text, "", align, false)
+}
+
+// splitText wraps str into lines of at most w, like MultiCell does. Lines
+// of right to left documents are shaped but still in logical order; lines
+// of documents with fallback fonts are not encoded yet.
+func (doc *Document) splitText(str string, w float64) []string {
+	if !doc.isRTL() && doc.fonts == nil {
+		return doc.pdf.SplitText(doc.encodeString(str), w)
+	}
+
+	if doc.isRTL() {
+		str = shapeArabic(str)
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(str, "\n") {
+		if split := doc.wrapLine(paragraph, w); len(split) > 0 {
+			lines = append(lines, split...)
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	return lines
+}
+
+// numberRun matches the numbers of a line with their sign, currency symbol,
+// percent sign and digit group separators, e.g. "-€ 1 234.50" or "17 %"
+var numberRun = regexp.MustCompile(`[-+]?(?:\p{Sc}\s?)?[-+]?\d(?:[\d.,'\x{00A0}\x{202F}]|\s\d)*(?:\s?[%\p{Sc}])?`)
+
+// numberMark is the first of the private use characters, strong left to
+// right ones, standing for the numbers of a line while it is reordered
+const numberMark = 0xF0000
+
+// hasRTL reports whether s has a right to left letter
+func hasRTL(s string) bool {
+	for _, r := range s {
+		if p, _ := bidi.LookupRune(r); p.Class() == bidi.R || p.Class() == bidi.AL {
+			return true
+		}
+	}
+	return false
+}
+
+// visualOrder reorders the logical text of a right to left line for
+// display: runs are laid out right to left, and the characters of right to
+// left runs are reversed while numbers and Latin words keep their order.
+// Lines without right to left letters, such as amounts, are left as is, and
+// numbers are kept whole: the spaces grouping digits would otherwise let
+// the groups swap.
+func visualOrder(line string) string {
+	if !hasRTL(line) {
+		return line
+	}
+
+	var numbers []string
+	line = numberRun.ReplaceAllStringFunc(line, func(number string) string {
+		numbers = append(numbers, number)
+		return string(rune(numberMark + len(numbers) - 1))
+	})
+
+	var p bidi.Paragraph
+	if _, err := p.SetString(line, bidi.DefaultDirection(bidi.RightToLeft)); err != nil {
+		return restoreNumbers(line, numbers)
+	}
+	ordering, err := p.Order()
+	if err != nil || ordering.NumRuns() == 0 {
+		return restoreNumbers(line, numbers)
+	}
+
+	var b strings.Builder
+	for i := ordering.NumRuns() - 1; i >= 0; i-- {
+		run := ordering.Run(i)
+		if run.Direction() == bidi.RightToLeft {
+			b.WriteString(bidi.ReverseString(run.String()))
+		} else {
+			b.WriteString(run.String())
+		}
+	}
+	return restoreNumbers(b.String(), numbers)
+}
+
+// restoreNumbers replaces the marks of line with the numbers they stand for
+func restoreNumbers(line string, numbers []string) string {
+	if len(numbers) == 0 {
+		return line
+	}
+
+	var b strings.Builder
+	for _, r := range line {
+		if i := int(r) - numberMark; i >= 0 && i < len(numbers) {
+			b.WriteString(numbers[i])
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// arabicForms holds the isolated, final, initial and medial presentation
+// forms of Arabic letters. Letters with two forms only join the previous
+// letter.
+var arabicForms = map[rune][]rune{
+	0x0621: {0xFE80},
+	0x0622: {0xFE81, 0xFE82},
+	0x0623: {0xFE83, 0xFE84},
+	0x0624: {0xFE85, 0xFE86},
+	0x0625: {0xFE87, 0xFE88},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA},
+	0x0630: {0xFEAB, 0xFEAC},
+	0x0631: {0xFEAD, 0xFEAE},
+	0x0632: {0xFEAF, 0xFEB0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0640: {0x0640, 0x0640, 0x0640, 0x0640},
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE},
+	0x0649: {0xFEEF, 0xFEF0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	0x067E: {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	0x0686: {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	0x0698: {0xFB8A, 0xFB8B},
+	0x06A9: {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	0x06AF: {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	0x06CC: {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlef holds the isolated and final lam-alef ligatures, by alef
+var lamAlef = map[rune][]rune{
+	0x0622: {0xFEF5, 0xFEF6},
+	0x0623: {0xFEF7, 0xFEF8},
+	0x0625: {0xFEF9, 0xFEFA},
+	0x0627: {0xFEFB, 0xFEFC},
+}
+
+// isArabicMark reports whether r is a harakat mark, transparent to joining
+func isArabicMark(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+// joinsNext reports whether the letter r connects to the letter after it
+func joinsNext(r rune) bool {
+	return len(arabicForms[r]) == 4
+}
+
+// shapeArabic replaces the Arabic letters of s with their contextual
+// presentation forms, as fpdf draws one glyph per character without
+// shaping. s is in logical order.
+func shapeArabic(s string) string {
+	runes := []rune(s)
+
+	// neighbour returns the closest letter before (step -1) or after (step 1)
+	// i, skipping marks
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if !isArabicMark(runes[j]) {
+				return runes[j]
+			}
+		}
+		return 0
+	}
+
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+
+		joinsPrev := len(forms) > 1 && joinsNext(neighbour(i, -1))
+
+		if r == 0x0644 && i+1 < len(runes) {
+			if ligature, ok := lamAlef[runes[i+1]]; ok {
+				if joinsPrev {
+					out = append(out, ligature[1])
+				} else {
+					out = append(out, ligature[0])
+				}
+				i++
+				continue
+			}
+		}
+
+		_, nextIsLetter := arabicForms[neighbour(i, 1)]
+		joins := joinsNext(r) && nextIsLetter && neighbour(i, 1) != 0x0621
+
+		switch {
+		case joinsPrev && joins:
+			out = append(out, forms[3])
+		case joins:
+			out = append(out, forms[2])
+		case joinsPrev:
+			out = append(out, forms[1])
+		default:
+			out = append(out, forms[0])
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,32 @@
+package generator
+
+// recipients returns the contact of the customer block and the contact
+// drawn below it, nil without ship-to contact, with the title labels of the
+// latter. Delivery notes put the ship-to contact in the customer block.
+func (doc *Document) recipients() (primary, other *Contact, title, secondaryTitle string) {
+	secondary := doc.secondary()
+	switch {
+	case doc.ShipTo == nil:
+		return doc.Customer, nil, "", ""
+	case doc.Type == DeliveryNote:
+		return doc.ShipTo, doc.Customer, doc.Options.TextBillToTitle, secondary.TextBillToTitle
+	default:
+		return doc.Customer, doc.ShipTo, doc.Options.TextShipToTitle, secondary.TextShipToTitle
+	}
+}
+
+// appendContactBelowCustomer draws c below the customer block, which ends
+// at y, under a title, and returns the Y below it
+func (c *Contact) appendContactBelowCustomer(doc *Document, fill bool, y float64, title, secondaryTitle string) float64 {
+	st := doc.style()
+	x := doc.rightColumnX() + rightColumnWidth - contactWidth
+	y += 4
+
+	doc.pdf.SetXY(doc.mirrorX(x, contactWidth), y)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", st.SmallFontSize)
+	doc.pdf.SetTextColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+	doc.labelCell(contactWidth, 4, title, secondaryTitle, "L")
+	doc.pdf.SetTextColor(doc.Options.BaseTextColor[0], doc.Options.BaseTextColor[1], doc.Options.BaseTextColor[2])
+
+	return c.appendContactTODoc(x, y+4, contactWidth, fill, contactWidth, doc)
+}
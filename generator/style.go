@@ -0,0 +1,76 @@
+package generator
+
+// Style holds the typography and spacing of a document. Every
+// document has its own style, so documents with different styles can be
+// rendered concurrently. Sizes are in points, distances in millimeters.
+type Style struct {
+	BaseFontSize       float64 `default:"8" json:"base_font_size,omitempty"`
+	SmallFontSize      float64 `default:"7" json:"small_font_size,omitempty"`
+	ExtraSmallFontSize float64 `default:"6" json:"extra_small_font_size,omitempty"`
+	LargeFontSize      float64 `default:"10" json:"large_font_size,omitempty"`
+	TitleFontSize      float64 `default:"14" json:"title_font_size,omitempty"`
+	NotesFontSize      float64 `default:"9" json:"notes_font_size,omitempty"`
+
+	// Margin is the left and right page margin
+	Margin          float64 `default:"10" json:"margin,omitempty"`
+	MarginTop       float64 `default:"20" json:"margin_top,omitempty"`
+	HeaderMarginTop float64 `default:"5" json:"header_margin_top,omitempty"`
+
+	// MarginBottom is the space below which text automatically continues on
+	// the next page
+	MarginBottom float64 `default:"20" json:"margin_bottom,omitempty"`
+
+	// MaxPageHeight is the Y past which blocks that must stay together (an
+	// item row, the totals) move to a new page. Zero keeps 37 mm free at the
+	// bottom of the page for the footer.
+	MaxPageHeight float64 `json:"max_page_height,omitempty"`
+
+	// SectionSpacing separates the description, the notes and the totals
+	// from the content above them
+	SectionSpacing float64 `default:"10" json:"section_spacing,omitempty"`
+
+	// ItemRowPadding is the space above and below the separator line of
+	// item rows
+	ItemRowPadding float64 `default:"3" json:"item_row_padding,omitempty"`
+}
+
+// footerReserve is the space kept free for the footer at the bottom of the
+// page when Style.MaxPageHeight is zero
+const footerReserve float64 = 37
+
+// setFontSizeDefaults gives the zero font sizes of st the value of the
+// deprecated package variables, still set by older callers
+func (st *Style) setFontSizeDefaults() {
+	for _, size := range []struct {
+		field *float64
+		value float64
+	}{
+		{&st.BaseFontSize, BaseTextFontSize},
+		{&st.SmallFontSize, SmallTextFontSize},
+		{&st.ExtraSmallFontSize, ExtraSmallTextFontSize},
+		{&st.LargeFontSize, LargeTextFontSize},
+	} {
+		if *size.field == 0 {
+			*size.field = size.value
+		}
+	}
+}
+
+// style returns the style of the document, with the margins of its
+// letterhead while it is rendered
+func (doc *Document) style() *Style {
+	if doc.letterheadStyle != nil {
+		return doc.letterheadStyle
+	}
+	return &doc.Options.Style
+}
+
+// maxPageHeight returns the Y past which blocks move to a new page
+func (doc *Document) maxPageHeight() float64 {
+	st := doc.style()
+	if st.MaxPageHeight > 0 {
+		return st.MaxPageHeight
+	}
+	_, pageHeight := doc.pdf.GetPageSize()
+	return pageHeight - max(footerReserve, st.MarginBottom)
+}
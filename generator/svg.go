@@ -0,0 +1,720 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// errInvalidSVG is returned for SVG logos without a usable size
+var errInvalidSVG = errors.New("svg has no viewBox nor width and height")
+
+// svgImage is a parsed SVG logo: its shapes as absolute paths in the
+// coordinates of its viewBox
+type svgImage struct {
+	minX, minY, width, height float64
+	shapes                    []svgShape
+}
+
+// svgShape is a path with its paint. Fill and stroke are nil when not
+// painted.
+type svgShape struct {
+	path        []svgSegment
+	fill        []int
+	stroke      []int
+	strokeWidth float64
+	evenOdd     bool
+	opacity     float64
+}
+
+// svgSegment is a path command: 'M' and 'L' use the first two args, 'C'
+// all six (two control points, then the end point), 'Z' none
+type svgSegment struct {
+	cmd  byte
+	args [6]float64
+}
+
+// svgMatrix is an affine transform: x' = a*x + c*y + e, y' = b*x + d*y + f
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the transform applying n, then m
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// svgPaint is the inherited presentation state of an element
+type svgPaint struct {
+	fill, stroke []int
+	strokeWidth  float64
+	evenOdd      bool
+	opacity      float64
+	transform    svgMatrix
+}
+
+// isSVG reports whether logo looks like an SVG document
+func isSVG(logo []byte) bool {
+	head := logo[:min(len(logo), 1024)]
+	return bytes.Contains(head, []byte("<svg"))
+}
+
+// svgSkipped are the elements whose content is not drawn
+var svgSkipped = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "symbol": true, "marker": true,
+	"pattern": true, "linearGradient": true, "radialGradient": true,
+	"style": true, "metadata": true, "title": true, "desc": true, "text": true,
+}
+
+// parseSVG parses the paths, basic shapes and groups of an SVG document.
+// Gradients, patterns, clipping, text and CSS stylesheets are not supported.
+func parseSVG(data []byte) (*svgImage, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var img *svgImage
+	stack := []svgPaint{{fill: []int{0, 0, 0}, strokeWidth: 1, opacity: 1, transform: svgIdentity}}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			name := el.Name.Local
+			if svgSkipped[name] {
+				if err := dec.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			attrs := map[string]string{}
+			for _, attr := range el.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			paint := stack[len(stack)-1].with(attrs)
+			stack = append(stack, paint)
+
+			if name == "svg" && img == nil {
+				if img, err = newSVGImage(attrs); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if img == nil {
+				continue
+			}
+			if d, ok := svgShapePath(name, attrs); ok {
+				path, err := parseSVGPath(d, paint.transform)
+				if err != nil {
+					return nil, fmt.Errorf("svg %s: %w", name, err)
+				}
+				scale := math.Sqrt(math.Abs(paint.transform[0]*paint.transform[3] - paint.transform[1]*paint.transform[2]))
+				if !finitePath(path) || !finite(paint.strokeWidth*scale) {
+					// Coordinates out of range cannot be drawn
+					continue
+				}
+				img.shapes = append(img.shapes, svgShape{
+					path:        path,
+					fill:        paint.fill,
+					stroke:      paint.stroke,
+					strokeWidth: paint.strokeWidth * scale,
+					evenOdd:     paint.evenOdd,
+					opacity:     paint.opacity,
+				})
+			}
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if img == nil {
+		return nil, errInvalidSVG
+	}
+	return img, nil
+}
+
+// newSVGImage reads the viewBox, or the width and height, of the root svg
+// element. Its aspect ratio must be finite and not zero.
+func newSVGImage(attrs map[string]string) (*svgImage, error) {
+	img := &svgImage{}
+	if vb := svgNumbers(attrs["viewBox"]); len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+		img.minX, img.minY, img.width, img.height = vb[0], vb[1], vb[2], vb[3]
+	} else {
+		img.width, img.height = svgLength(attrs["width"]), svgLength(attrs["height"])
+	}
+	if ratio := img.width / img.height; img.width <= 0 || img.height <= 0 || ratio == 0 || !finite(ratio) {
+		return nil, errInvalidSVG
+	}
+	return img, nil
+}
+
+// with returns p updated with the presentation attributes and style of an
+// element
+func (p svgPaint) with(attrs map[string]string) svgPaint {
+	props := map[string]string{}
+	for _, name := range []string{"fill", "stroke", "stroke-width", "fill-rule", "opacity", "fill-opacity"} {
+		if v, ok := attrs[name]; ok {
+			props[name] = v
+		}
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if name, v, ok := strings.Cut(decl, ":"); ok {
+			props[strings.TrimSpace(name)] = strings.TrimSpace(v)
+		}
+	}
+
+	if v, ok := props["fill"]; ok {
+		p.fill = svgColor(v)
+	}
+	if v, ok := props["stroke"]; ok {
+		p.stroke = svgColor(v)
+	}
+	if v, ok := props["stroke-width"]; ok {
+		p.strokeWidth = svgLength(v)
+	}
+	if v, ok := props["fill-rule"]; ok {
+		p.evenOdd = v == "evenodd"
+	}
+	for _, name := range []string{"opacity", "fill-opacity"} {
+		if v, err := strconv.ParseFloat(props[name], 64); err == nil {
+			p.opacity *= math.Max(0, math.Min(1, v))
+		}
+	}
+	if v, ok := attrs["transform"]; ok {
+		p.transform = p.transform.mul(parseSVGTransform(v))
+	}
+	return p
+}
+
+// svgShapePath returns the path data of a path or basic shape element
+func svgShapePath(name string, attrs map[string]string) (string, bool) {
+	n := func(key string) float64 { return svgLength(attrs[key]) }
+	switch name {
+	case "path":
+		return attrs["d"], true
+	case "rect":
+		x, y, w, h := n("x"), n("y"), n("width"), n("height")
+		rx, ry := n("rx"), n("ry")
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+		if rx <= 0 || ry <= 0 {
+			return fmt.Sprintf("M%g %gH%gV%gH%gZ", x, y, x+w, y+h, x), true
+		}
+		return fmt.Sprintf("M%g %gH%gA%g %g 0 0 1 %g %gV%gA%g %g 0 0 1 %g %gH%gA%g %g 0 0 1 %g %gV%gA%g %g 0 0 1 %g %gZ",
+			x+rx, y, x+w-rx, rx, ry, x+w, y+ry, y+h-ry, rx, ry, x+w-rx, y+h, x+rx, rx, ry, x, y+h-ry, y+ry, rx, ry, x+rx, y), true
+	case "circle", "ellipse":
+		cx, cy, rx, ry := n("cx"), n("cy"), n("r"), n("r")
+		if name == "ellipse" {
+			rx, ry = n("rx"), n("ry")
+		}
+		return fmt.Sprintf("M%g %gA%g %g 0 1 0 %g %gA%g %g 0 1 0 %g %gZ", cx-rx, cy, rx, ry, cx+rx, cy, rx, ry, cx-rx, cy), true
+	case "line":
+		return fmt.Sprintf("M%g %gL%g %g", n("x1"), n("y1"), n("x2"), n("y2")), true
+	case "polyline", "polygon":
+		d := "M" + attrs["points"]
+		if name == "polygon" {
+			d += "Z"
+		}
+		return d, true
+	}
+	return "", false
+}
+
+// svgNamedColors are the color keywords recognised besides #rgb, #rrggbb and
+// rgb()
+var svgNamedColors = map[string][]int{
+	"black": {0, 0, 0}, "white": {255, 255, 255}, "red": {255, 0, 0},
+	"green": {0, 128, 0}, "blue": {0, 0, 255}, "yellow": {255, 255, 0},
+	"orange": {255, 165, 0}, "purple": {128, 0, 128}, "navy": {0, 0, 128},
+	"gray": {128, 128, 128}, "grey": {128, 128, 128}, "silver": {192, 192, 192},
+}
+
+// svgColor parses a paint value, nil for none. Unsupported paints (e.g.
+// gradients) are drawn black.
+func svgColor(v string) []int {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case v == "none" || v == "transparent":
+		return nil
+	case strings.HasPrefix(v, "#") && len(v) == 4:
+		c := make([]int, 3)
+		for i := range c {
+			n, _ := strconv.ParseUint(v[1+i:2+i], 16, 8)
+			c[i] = int(n * 17)
+		}
+		return c
+	case strings.HasPrefix(v, "#") && len(v) == 7:
+		c := make([]int, 3)
+		for i := range c {
+			n, _ := strconv.ParseUint(v[1+2*i:3+2*i], 16, 8)
+			c[i] = int(n)
+		}
+		return c
+	case strings.HasPrefix(v, "rgb(") && strings.HasSuffix(v, ")"):
+		if n := svgNumbers(v[4 : len(v)-1]); len(n) == 3 {
+			return []int{int(n[0]), int(n[1]), int(n[2])}
+		}
+	}
+	if c, ok := svgNamedColors[v]; ok {
+		return c
+	}
+	return []int{0, 0, 0}
+}
+
+// svgLength parses a length in user units, ignoring its unit
+func svgLength(v string) float64 {
+	n := svgNumbers(v)
+	if len(n) == 0 {
+		return 0
+	}
+	return n[0]
+}
+
+// svgNumbers returns the numbers of a list separated by spaces or commas
+func svgNumbers(v string) []float64 {
+	s := &svgScanner{s: v}
+	var numbers []float64
+	for s.more() {
+		n, ok := s.number()
+		if !ok {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+// parseSVGTransform parses a transform attribute
+func parseSVGTransform(v string) svgMatrix {
+	m := svgIdentity
+	for _, fn := range strings.Split(v, ")") {
+		name, args, ok := strings.Cut(fn, "(")
+		if !ok {
+			continue
+		}
+		n := svgNumbers(args)
+		arg := func(i int, def float64) float64 {
+			if i < len(n) {
+				return n[i]
+			}
+			return def
+		}
+
+		var t svgMatrix
+		switch strings.Trim(strings.TrimSpace(name), ",") {
+		case "matrix":
+			if len(n) != 6 {
+				continue
+			}
+			t = svgMatrix(n)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			t = svgMatrix{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				mul(svgMatrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+	return m
+}
+
+// svgScanner reads the numbers and commands of path data
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (s *svgScanner) skipSeparators() {
+	for s.i < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+// more reports whether a number follows
+func (s *svgScanner) more() bool {
+	s.skipSeparators()
+	return s.i < len(s.s) && strings.IndexByte("+-.0123456789", s.s[s.i]) >= 0
+}
+
+// command returns the next command letter
+func (s *svgScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.i >= len(s.s) {
+		return 0, false
+	}
+	c := s.s[s.i]
+	s.i++
+	return c, true
+}
+
+func (s *svgScanner) number() (float64, bool) {
+	s.skipSeparators()
+	start := s.i
+	digits := func() {
+		for s.i < len(s.s) && s.s[s.i] >= '0' && s.s[s.i] <= '9' {
+			s.i++
+		}
+	}
+	if s.i < len(s.s) && (s.s[s.i] == '+' || s.s[s.i] == '-') {
+		s.i++
+	}
+	digits()
+	if s.i < len(s.s) && s.s[s.i] == '.' {
+		s.i++
+		digits()
+	}
+	if s.i < len(s.s) && (s.s[s.i] == 'e' || s.s[s.i] == 'E') {
+		s.i++
+		if s.i < len(s.s) && (s.s[s.i] == '+' || s.s[s.i] == '-') {
+			s.i++
+		}
+		digits()
+	}
+	n, err := strconv.ParseFloat(s.s[start:s.i], 64)
+	return n, err == nil
+}
+
+// flag reads an arc flag, which may not be followed by a separator
+func (s *svgScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.i >= len(s.s) || (s.s[s.i] != '0' && s.s[s.i] != '1') {
+		return false, false
+	}
+	s.i++
+	return s.s[s.i-1] == '1', true
+}
+
+// parseSVGPath converts path data to absolute moves, lines and cubic
+// curves, transformed by m
+func parseSVGPath(d string, m svgMatrix) ([]svgSegment, error) {
+	s := &svgScanner{s: d}
+	var path []svgSegment
+	var x, y, startX, startY float64
+
+	// lastCtrl is the last control point, reflected by smooth curves
+	var lastCtrlX, lastCtrlY float64
+	var lastCmd byte
+
+	add := func(cmd byte, pts ...float64) {
+		seg := svgSegment{cmd: cmd}
+		for i := 0; i+1 < len(pts); i += 2 {
+			seg.args[i], seg.args[i+1] = m.apply(pts[i], pts[i+1])
+		}
+		path = append(path, seg)
+	}
+	cubic := func(c1x, c1y, c2x, c2y, ex, ey float64) {
+		add('C', c1x, c1y, c2x, c2y, ex, ey)
+		lastCtrlX, lastCtrlY = c2x, c2y
+		x, y = ex, ey
+	}
+
+	var cmd byte
+	for {
+		if c, ok := s.command(); !ok {
+			break
+		} else if strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+		} else if cmd != 0 && strings.IndexByte("+-.0123456789", c) >= 0 {
+			// Implicit repetition of the previous command
+			s.i--
+		} else {
+			return nil, fmt.Errorf("unexpected %q in path data", c)
+		}
+
+		rel := cmd >= 'a'
+		abs := func(dx, dy float64) (float64, float64) {
+			if rel {
+				return x + dx, y + dy
+			}
+			return dx, dy
+		}
+
+		args := func(n int) ([]float64, bool) {
+			v := make([]float64, n)
+			for i := range v {
+				var ok bool
+				if v[i], ok = s.number(); !ok {
+					return nil, false
+				}
+			}
+			return v, true
+		}
+
+		switch cmd {
+		case 'Z', 'z':
+			add('Z')
+			x, y = startX, startY
+			lastCmd = cmd
+			continue
+		case 'A', 'a':
+			rx, okRx := s.number()
+			ry, okRy := s.number()
+			phi, okPhi := s.number()
+			large, okLarge := s.flag()
+			sweep, okSweep := s.flag()
+			end, okEnd := args(2)
+			if !okRx || !okRy || !okPhi || !okLarge || !okSweep || !okEnd {
+				return nil, errors.New("invalid arc")
+			}
+			ex, ey := abs(end[0], end[1])
+			curves := svgArc(x, y, rx, ry, phi, large, sweep, ex, ey)
+			if curves == nil && (ex != x || ey != y) {
+				add('L', ex, ey)
+			}
+			for _, c := range curves {
+				cubic(c[0], c[1], c[2], c[3], c[4], c[5])
+			}
+			x, y = ex, ey
+		default:
+			n := map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2}[cmd&^0x20]
+			v, ok := args(n)
+			if !ok {
+				return nil, fmt.Errorf("missing %c arguments", cmd)
+			}
+
+			switch cmd &^ 0x20 {
+			case 'M':
+				x, y = abs(v[0], v[1])
+				startX, startY = x, y
+				add('M', x, y)
+				// Following coordinate pairs are lines
+				if rel {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			case 'L':
+				x, y = abs(v[0], v[1])
+				add('L', x, y)
+			case 'H':
+				if rel {
+					x += v[0]
+				} else {
+					x = v[0]
+				}
+				add('L', x, y)
+			case 'V':
+				if rel {
+					y += v[0]
+				} else {
+					y = v[0]
+				}
+				add('L', x, y)
+			case 'C':
+				c1x, c1y := abs(v[0], v[1])
+				c2x, c2y := abs(v[2], v[3])
+				ex, ey := abs(v[4], v[5])
+				cubic(c1x, c1y, c2x, c2y, ex, ey)
+			case 'S':
+				c1x, c1y := x, y
+				if strings.IndexByte("CcSs", lastCmd) >= 0 {
+					c1x, c1y = 2*x-lastCtrlX, 2*y-lastCtrlY
+				}
+				c2x, c2y := abs(v[0], v[1])
+				ex, ey := abs(v[2], v[3])
+				cubic(c1x, c1y, c2x, c2y, ex, ey)
+			case 'Q', 'T':
+				qx, qy := x, y
+				var ex, ey float64
+				if cmd&^0x20 == 'Q' {
+					qx, qy = abs(v[0], v[1])
+					ex, ey = abs(v[2], v[3])
+				} else {
+					if strings.IndexByte("QqTt", lastCmd) >= 0 {
+						qx, qy = 2*x-lastCtrlX, 2*y-lastCtrlY
+					}
+					ex, ey = abs(v[0], v[1])
+				}
+				// Quadratic curves are drawn as their cubic equivalent
+				cubic(x+2*(qx-x)/3, y+2*(qy-y)/3, ex+2*(qx-ex)/3, ey+2*(qy-ey)/3, ex, ey)
+				lastCtrlX, lastCtrlY = qx, qy
+			}
+		}
+		lastCmd = cmd
+	}
+	return path, nil
+}
+
+// svgArc converts an SVG elliptical arc from (x1, y1) to (x2, y2) to cubic
+// curves (c1x, c1y, c2x, c2y, x, y), nil when it is a straight line or when
+// its radii are too large to compute it
+func svgArc(x1, y1, rx, ry, phiDeg float64, large, sweep bool, x2, y2 float64) [][6]float64 {
+	if (x1 == x2 && y1 == y2) || rx == 0 || ry == 0 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	phi := phiDeg * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+
+	// Center parameterization, see SVG 1.1 implementation notes F.6.5
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p, y1p := cos*dx+sin*dy, -sin*dx+cos*dy
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+	cx, cy := cos*cxp-sin*cyp+(x1+x2)/2, sin*cxp+cos*cyp+(y1+y2)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	if !finite(cx, cy, theta, delta) {
+		return nil
+	}
+
+	// One curve per quarter turn at most
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	if n < 1 {
+		return nil
+	}
+	step := delta / float64(n)
+	t := 4.0 / 3.0 * math.Tan(step/4)
+	point := func(ux, uy float64) (float64, float64) {
+		return cx + rx*cos*ux - ry*sin*uy, cy + rx*sin*ux + ry*cos*uy
+	}
+
+	curves := make([][6]float64, n)
+	for i := range curves {
+		a1 := theta + float64(i)*step
+		a2 := a1 + step
+		c1x, c1y := point(math.Cos(a1)-t*math.Sin(a1), math.Sin(a1)+t*math.Cos(a1))
+		c2x, c2y := point(math.Cos(a2)+t*math.Sin(a2), math.Sin(a2)-t*math.Cos(a2))
+		ex, ey := point(math.Cos(a2), math.Sin(a2))
+		if !finite(c1x, c1y, c2x, c2y, ex, ey) {
+			return nil
+		}
+		curves[i] = [6]float64{c1x, c1y, c2x, c2y, ex, ey}
+	}
+	// End exactly on the target point
+	curves[n-1][4], curves[n-1][5] = x2, y2
+	return curves
+}
+
+// finite reports whether none of values is infinite or NaN
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// finitePath reports whether the coordinates of path are all finite
+func finitePath(path []svgSegment) bool {
+	for _, seg := range path {
+		if !finite(seg.args[:]...) {
+			return false
+		}
+	}
+	return true
+}
+
+// drawSVG draws img as vectors in the w × h box at x, y
+func (doc *Document) drawSVG(img *svgImage, x, y, w, h float64) {
+	scale := math.Min(w/img.width, h/img.height)
+	px := func(sx, sy float64) (float64, float64) {
+		return x + (sx-img.minX)*scale, y + (sy-img.minY)*scale
+	}
+
+	fr, fg, fb := doc.pdf.GetFillColor()
+	dr, dg, db := doc.pdf.GetDrawColor()
+	lineWidth := doc.pdf.GetLineWidth()
+
+	for _, shape := range img.shapes {
+		style := ""
+		if shape.fill != nil {
+			style += "F"
+			doc.pdf.SetFillColor(shape.fill[0], shape.fill[1], shape.fill[2])
+		}
+		if shape.stroke != nil && shape.strokeWidth > 0 {
+			style += "D"
+			doc.pdf.SetDrawColor(shape.stroke[0], shape.stroke[1], shape.stroke[2])
+			doc.pdf.SetLineWidth(shape.strokeWidth * scale)
+		}
+		if len(style) == 0 || len(shape.path) == 0 {
+			continue
+		}
+		if shape.evenOdd && shape.fill != nil {
+			style += "*"
+		}
+		if shape.opacity < 1 {
+			doc.pdf.SetAlpha(shape.opacity, "Normal")
+		}
+
+		for _, seg := range shape.path {
+			switch seg.cmd {
+			case 'M':
+				doc.pdf.MoveTo(px(seg.args[0], seg.args[1]))
+			case 'L':
+				doc.pdf.LineTo(px(seg.args[0], seg.args[1]))
+			case 'C':
+				c1x, c1y := px(seg.args[0], seg.args[1])
+				c2x, c2y := px(seg.args[2], seg.args[3])
+				ex, ey := px(seg.args[4], seg.args[5])
+				doc.pdf.CurveBezierCubicTo(c1x, c1y, c2x, c2y, ex, ey)
+			case 'Z':
+				doc.pdf.ClosePath()
+			}
+		}
+		doc.pdf.DrawPath(style)
+
+		if shape.opacity < 1 {
+			doc.pdf.SetAlpha(1, "Normal")
+		}
+	}
+
+	doc.pdf.SetFillColor(fr, fg, fb)
+	doc.pdf.SetDrawColor(dr, dg, db)
+	doc.pdf.SetLineWidth(lineWidth)
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSVGArcDegenerate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		d    string
+	}{
+		{"huge radii", "M0 0 A1e200 1e200 0 0 1 10 10"},
+		{"huge coordinates", "M-1e308 0 A1 1 0 0 1 1e308 0"},
+		{"tiny radii", "M0 0 A1e-300 1e-300 0 1 0 10 10"},
+		{"zero radius", "M0 0 A0 5 0 0 1 10 10"},
+		{"same point", "M5 5 A5 5 0 1 1 5 5"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := parseSVGPath(tt.d, svgIdentity)
+			if err != nil {
+				t.Fatalf("parseSVGPath: %v", err)
+			}
+			for _, seg := range path {
+				for _, v := range seg.args {
+					if math.IsNaN(v) {
+						t.Fatalf("NaN in %+v", path)
+					}
+				}
+			}
+		})
+	}
+
+	img, err := parseSVG([]byte(`<svg viewBox="0 0 10 10"><path d="M0 0 A1e200 1e200 0 0 1 10 10"/><g transform="scale(1e200)"><rect width="1e200" height="1"/></g></svg>`))
+	if err != nil {
+		t.Fatalf("parseSVG: %v", err)
+	}
+	if len(img.shapes) != 1 {
+		t.Fatalf("got %d shapes, want the arc drawn as a line and the rect out of range skipped", len(img.shapes))
+	}
+
+	if _, err := parseSVG([]byte(`<svg viewBox="0 0 1e308 1e-308"/>`)); err == nil {
+		t.Fatalf("expected an error for an infinite aspect ratio")
+	}
+}
+
+func FuzzParseSVG(f *testing.F) {
+	f.Add([]byte(`<svg viewBox="0 0 200 50"><g transform="translate(25 25)" style="fill:#e53935"><circle r="15"/></g><path d="M50 35 Q60 10 70 35 T90 35 A10 5 30 1 1 110 35z"/><polygon points="130,10 150,40 170,10"/></svg>`))
+	f.Add([]byte(`<svg width="10" height="10"><path d="M0 0 A1e200 1e200 0 0 1 10 10"/></svg>`))
+	f.Add([]byte(`<svg viewBox="0 0 10 10"><ellipse rx="3" ry="4" transform="rotate(30) skewX(10)"/><line x2="5" y2="5" stroke="red"/></svg>`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		img, err := parseSVG(data)
+		if err != nil {
+			return
+		}
+		if img.width <= 0 || img.height <= 0 {
+			t.Fatalf("invalid size %vx%v", img.width, img.height)
+		}
+		for _, shape := range img.shapes {
+			if !finitePath(shape.path) || !finite(shape.strokeWidth) {
+				t.Fatalf("non finite shape %+v", shape)
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Text returns the document as plain text, for support tools and
+// notifications. It has the labels, currency formatting and totals of the
+// PDF; items are aligned in columns, accounting for wide (CJK) and
+// combining characters.
+func (doc *Document) Text() (string, error) {
+	var buf bytes.Buffer
+	if err := doc.RenderText(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderText writes the document as plain text to w, see Text
+func (doc *Document) RenderText(w io.Writer) error {
+	v, err := doc.view()
+	if err != nil {
+		return err
+	}
+
+	var b textBuilder
+	b.line(v.Title)
+	b.line(strings.Repeat("=", runewidth.StringWidth(v.Title)))
+	for _, meta := range v.Metas {
+		b.line(meta)
+	}
+
+	for _, c := range v.contacts() {
+		b.line("")
+		if len(c.Title) > 0 {
+			b.line(c.Title + ":")
+		}
+		b.line(c.Name)
+		for _, l := range c.Lines {
+			b.line(l)
+		}
+		for _, detail := range c.Details {
+			b.line(detail)
+		}
+		for _, info := range c.Info {
+			b.line(stripBasicHTML(info))
+		}
+	}
+
+	if len(v.Description) > 0 {
+		b.line("")
+		b.line(v.Description)
+	}
+
+	// Items table, detail lines below the row they belong to
+	header := make([]string, len(v.Columns))
+	aligns := make([]string, len(v.Columns))
+	for i, col := range v.Columns {
+		header[i] = doc.label(col.Label, col.SecondaryLabel)
+		aligns[i] = col.Align
+	}
+	rows := [][]string{header, nil}
+	for _, row := range v.Rows {
+		texts, details := make([]string, len(row)), make([]string, len(row))
+		hasDetail := false
+		for i, cell := range row {
+			texts[i], details[i] = cell.Text, cell.Detail
+			hasDetail = hasDetail || len(cell.Detail) > 0
+		}
+		rows = append(rows, texts)
+		if hasDetail {
+			rows = append(rows, details)
+		}
+	}
+
+	widths := columnWidths(rows)
+	b.line("")
+	for _, row := range rows {
+		cells := make([]string, len(widths))
+		for i, width := range widths {
+			if row == nil {
+				cells[i] = strings.Repeat("-", width)
+				continue
+			}
+			cells[i] = padCell(row[i], width, aligns[i])
+		}
+		b.line(strings.Join(cells, "  "))
+	}
+
+	// Totals, right aligned under the table
+	tableWidth := 2 * (len(widths) - 1)
+	for _, width := range widths {
+		tableWidth += width
+	}
+	var totals [][]string
+	for _, tl := range v.Totals {
+		totals = append(totals, []string{tl.Label, tl.Amount})
+		if len(tl.Detail) > 0 {
+			totals = append(totals, []string{tl.Detail, ""})
+		}
+	}
+	totalWidths := columnWidths(totals)
+	indent := max(tableWidth-totalWidths[0]-totalWidths[1]-2, 0)
+	b.line("")
+	for _, row := range totals {
+		b.line(strings.Repeat(" ", indent) + padCell(row[0], totalWidths[0], AlignRight) + "  " + padCell(row[1], totalWidths[1], AlignRight))
+	}
+
+	if len(v.InWords) > 0 {
+		b.line("")
+		b.line(v.InWords)
+	}
+	if len(v.PaymentTerm) > 0 {
+		b.line("")
+		b.line(v.PaymentTerm)
+	}
+	if len(v.Payment) > 0 {
+		b.line("")
+		b.line(v.PaymentTitle + ":")
+		for _, line := range v.Payment {
+			b.line(line[0] + ": " + line[1])
+		}
+	}
+	if len(v.Notes) > 0 {
+		b.line("")
+		b.line(stripBasicHTML(v.Notes))
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// textBuilder writes the lines of text and Markdown renderings, without
+// trailing spaces
+type textBuilder struct {
+	strings.Builder
+}
+
+func (b *textBuilder) line(s string) {
+	for _, l := range strings.Split(s, "\n") {
+		b.WriteString(strings.TrimRight(l, " "))
+		b.WriteByte('\n')
+	}
+}
+
+// columnWidths returns the display width of the widest cell of each column.
+// Nil rows are ignored.
+func columnWidths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], runewidth.StringWidth(cell))
+		}
+	}
+	return widths
+}
+
+// padCell pads s with spaces to width display cells, as align requires
+func padCell(s string, width int, align string) string {
+	pad := max(width-runewidth.StringWidth(s), 0)
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", pad) + s
+	case AlignCenter:
+		return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2)
+	default:
+		return s + strings.Repeat(" ", pad)
+	}
+}
+
+// stripBasicHTML removes the bold, italic, underline tags of s and turns its
+// line breaks tags into newlines
+func stripBasicHTML(s string) string {
+	return basicHTMLTag.ReplaceAllStringFunc(s, func(tag string) string {
+		if strings.EqualFold(basicHTMLTag.FindStringSubmatch(tag)[2], "br") {
+			return "\n"
+		}
+		return ""
+	})
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestText(t *testing.T) {
+	doc := goldenDoc(t, &Options{AmountInWords: true, AmountInWordsCurrency: "USD", CurrencySymbol: "$"})
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text: %v", err)
+	}
+	assertGolden(t, "invoice.txt", []byte(text))
+
+	// The wide characters of "Hosting 日本" must not shift the next columns
+	var rows []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "Design ") || strings.HasPrefix(line, "Hosting ") {
+			rows = append(rows, line)
+		}
+	}
+	lastColumn := func(row string) int {
+		return runewidth.StringWidth(row[:strings.LastIndex(row, "  ")])
+	}
+	if len(rows) != 2 || lastColumn(rows[0]) != lastColumn(rows[1]) {
+		t.Fatalf("misaligned rows %q", rows)
+	}
+
+	doc.Ref = ""
+	if _, err := doc.Text(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	doc := goldenDoc(t, &Options{Columns: []*Column{
+		{Key: ColumnName},
+		{Key: ColumnQuantity, Align: AlignCenter},
+		{Key: ColumnTotalTTC, Align: AlignRight},
+	}})
+
+	md, err := doc.Markdown()
+	if err != nil {
+		t.Fatalf("Markdown: %v", err)
+	}
+	assertGolden(t, "invoice.md", []byte(md))
+
+	if !strings.Contains(md, `\<script\>`) || !strings.Contains(md, "**Thank you**") {
+		t.Fatal("notes must keep bold text and escape other markup")
+	}
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Built-in themes
+const (
+	ThemeClassic string = "classic"
+	ThemeMinimal string = "minimal"
+	ThemeModern  string = "modern"
+)
+
+// Theme draws the blocks of a document. The generator calls it in reading
+// order and keeps handling pagination, totals computation and the items
+// table layout, so a theme only decides how each block looks.
+//
+// The right column (title, metas, customer, totals) is
+// Document.RightColumn, the items table spans Document.TableBounds and
+// its columns are Document.TableColumns. Embed ClassicTheme to only
+// override some blocks.
+type Theme interface {
+	// Title draws the document type at the top of the first page
+	Title(doc *Document)
+
+	// Metas draws the ref, version and dates below the title
+	Metas(doc *Document)
+
+	// Contacts draws the company and customer and leaves Y below them
+	Contacts(doc *Document)
+
+	// TableHeader draws the items table header, on the first page and after
+	// every page break inside the table, and leaves Y at the first row
+	TableHeader(doc *Document)
+
+	// Item draws an item row and leaves Y at its bottom. A row going past
+	// the max page height is moved to a new page. The generator separates
+	// rows with a line, Style.ItemRowPadding below and above it.
+	Item(doc *Document, item *Item)
+
+	// Notes draws the notes on the left of the totals and restores Y
+	Notes(doc *Document)
+
+	// Totals draws the totals in the right column and leaves Y below them
+	Totals(doc *Document)
+
+	// PaymentTerm draws the payment term below the totals
+	PaymentTerm(doc *Document)
+}
+
+// themes are the built-in themes, by name
+var themes = map[string]Theme{
+	ThemeClassic: ClassicTheme{},
+	ThemeMinimal: MinimalTheme{},
+	ThemeModern:  ModernTheme{},
+}
+
+// SetTheme draws the document with theme instead of Options.Theme
+func (d *Document) SetTheme(theme Theme) *Document {
+	d.customTheme = theme
+	return d
+}
+
+// theme returns the theme drawing the document
+func (doc *Document) theme() Theme {
+	if doc.customTheme != nil {
+		return doc.customTheme
+	}
+	if theme, ok := themes[doc.Options.Theme]; ok {
+		return theme
+	}
+	return ClassicTheme{}
+}
+
+// ClassicTheme is the default look: grey filled title, contacts, table
+// header and totals
+type ClassicTheme struct{}
+
+// Title draws the document type on a dark band
+func (ClassicTheme) Title(doc *Document) {
+	doc.appendTitle(doc.Options.DarkBgColor, doc.Options.BaseTextColor, "")
+}
+
+// Metas draws the ref, version and dates
+func (ClassicTheme) Metas(doc *Document) {
+	doc.appendMetas()
+}
+
+// Contacts draws the company and customer on grey boxes
+func (ClassicTheme) Contacts(doc *Document) {
+	doc.appendContacts(true)
+}
+
+// TableHeader draws the column labels on a grey band
+func (ClassicTheme) TableHeader(doc *Document) {
+	doc.appendTableHeader(doc.Options.GreyBgColor, doc.Options.BaseTextColor)
+}
+
+// Item draws an item row with the items table columns
+func (ClassicTheme) Item(doc *Document, item *Item) {
+	item.appendColTo(doc)
+}
+
+// Notes draws the notes
+func (ClassicTheme) Notes(doc *Document) {
+	doc.appendNotes()
+}
+
+// Totals draws the totals with dark labels and grey amounts
+func (ClassicTheme) Totals(doc *Document) {
+	doc.appendTotal(doc.classicTotalsStyle())
+}
+
+// PaymentTerm draws the payment term
+func (ClassicTheme) PaymentTerm(doc *Document) {
+	doc.appendPaymentTerm()
+}
+
+// RightColumn returns the left X and the width of the right column holding
+// the title, the metas, the customer and the totals
+func (doc *Document) RightColumn() (x, w float64) {
+	return doc.rightColumnX(), rightColumnWidth
+}
+
+// TableBounds returns the left and right X of the items table
+func (doc *Document) TableBounds() (left, right float64) {
+	return doc.tableBounds()
+}
+
+// TableColumns returns the visible items table columns while the document
+// is rendered
+func (doc *Document) TableColumns() []*TableColumn {
+	return doc.tableColumns
+}
+
+// EncodeString converts str with Options.UnicodeTranslateFunc for drawing
+func (doc *Document) EncodeString(str string) string {
+	return doc.encodeString(str)
+}
+
+// FormatMoney formats amount with the document currency options
+func (doc *Document) FormatMoney(amount decimal.Decimal) string {
+	return doc.ac.FormatMoneyDecimal(amount)
+}
+
+// Label returns primary followed by its secondary locale translation, when
+// the document is bilingual
+func (doc *Document) Label(primary, secondary string) string {
+	return doc.label(primary, secondary)
+}
+
+// LabelCell draws a primary label and its secondary locale translation in
+// a w × h cell at the current position, stacking them when they do not fit
+func (doc *Document) LabelCell(w, h float64, primary, secondary, alignStr string) {
+	doc.labelCell(w, h, primary, secondary, alignStr)
+}
+
+// SecondaryOptions returns the secondary locale labels, empty when the
+// document is not bilingual
+func (doc *Document) SecondaryOptions() *Options {
+	return doc.secondary()
+}
+
+// TypeLabel returns the document type label in opts
+func (doc *Document) TypeLabel(opts *Options) string {
+	return doc.typeAsString(opts)
+}
+
+// validateTheme reports unknown Options.Theme values of documents without a
+// custom theme
+func (doc *Document) validateTheme(verr *ValidationError) {
+	if doc.customTheme != nil || len(doc.Options.Theme) == 0 {
+		return
+	}
+	if _, ok := themes[doc.Options.Theme]; !ok {
+		verr.add("options.theme", ErrorCodeInvalid, fmt.Sprintf("unknown theme %q", doc.Options.Theme), nil)
+	}
+}
@@ -0,0 +1,34 @@
+package generator
+
+// MinimalTheme draws the document without filled boxes: plain contacts, a
+// thin line under the items table header and a bold total with tax
+type MinimalTheme struct {
+	ClassicTheme
+}
+
+// Title draws the document type in bold
+func (MinimalTheme) Title(doc *Document) {
+	doc.appendTitle(nil, doc.Options.BaseTextColor, "B")
+}
+
+// Contacts draws the company and customer without boxes
+func (MinimalTheme) Contacts(doc *Document) {
+	doc.appendContacts(false)
+}
+
+// TableHeader draws the column labels in grey, underlined
+func (MinimalTheme) TableHeader(doc *Document) {
+	doc.appendTableHeader(nil, doc.Options.GreyTextColor)
+
+	// The 6 mm header row ends 2 mm above the first item row
+	left, right := doc.tableBounds()
+	lineY := doc.pdf.GetY() - 2
+	doc.pdf.SetDrawColor(doc.Options.GreyTextColor[0], doc.Options.GreyTextColor[1], doc.Options.GreyTextColor[2])
+	doc.pdf.Line(left, lineY, right, lineY)
+	doc.pdf.SetDrawColor(0, 0, 0)
+}
+
+// Totals draws the totals without fill, the total with tax in bold
+func (MinimalTheme) Totals(doc *Document) {
+	doc.appendTotal(totalsStyle{totalBold: true})
+}
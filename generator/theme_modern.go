@@ -0,0 +1,40 @@
+package generator
+
+// modernBandHeight is the height of the accent band at the top of the first
+// page of the modern theme
+const modernBandHeight float64 = 4
+
+// ModernTheme draws an Options.AccentColor band at the top of the first
+// page, the title in the accent color and the items table header and the
+// total with tax on accent bands
+type ModernTheme struct {
+	ClassicTheme
+}
+
+// Title draws the accent band and the document type in the accent color
+func (ModernTheme) Title(doc *Document) {
+	pageWidth, _ := doc.pdf.GetPageSize()
+	doc.fillRect(doc.Options.AccentColor, 0, 0, pageWidth, modernBandHeight)
+
+	doc.appendTitle(nil, doc.Options.AccentColor, "B")
+}
+
+// TableHeader draws the column labels in white on an accent band
+func (ModernTheme) TableHeader(doc *Document) {
+	doc.appendTableHeader(doc.Options.AccentColor, white)
+}
+
+// Totals draws the totals with grey amounts and the total with tax in
+// white on an accent band
+func (ModernTheme) Totals(doc *Document) {
+	doc.appendTotal(totalsStyle{
+		amountBg:       doc.Options.GreyBgColor,
+		totalLabelBg:   doc.Options.AccentColor,
+		totalAmountBg:  doc.Options.AccentColor,
+		totalTextColor: white,
+		totalBold:      true,
+	})
+}
+
+// white is the text color drawn on accent bands
+var white = []int{255, 255, 255}
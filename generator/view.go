@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// documentView is the content of a document, with the labels and amounts
+// of the PDF already formatted, shared by the HTML and text renderers
+type documentView struct {
+	Title       string
+	Metas       []string
+	Company     contactView
+	Customer    contactView
+	OtherParty  *contactView
+	Description string
+	Columns     []*TableColumn
+	Rows        [][]Cell
+	Notes       string
+	Totals      []totalLine
+	InWords     string
+	PaymentTerm string
+
+	// Payment is the title and the label, value lines of the payment
+	// instructions block, empty without payment instructions
+	PaymentTitle string
+	Payment      [][2]string
+}
+
+// contactView is a contact of a documentView. The ship-to contact, or the
+// customer on delivery notes, is the other party, under a title.
+type contactView struct {
+	Title string
+	Name  string
+	Logo  []byte
+	Lines []string
+
+	// Details are the typed details of the contact, plain text, and Info
+	// its additional info lines, basic HTML
+	Details []string
+	Info    []string
+}
+
+// totalLine is a row of the totals block
+type totalLine struct {
+	Label string
+
+	// Detail is rendered below Label, like the discount description
+	Detail string
+	Amount string
+
+	// Sub marks the per-name tax breakdown, Grand the total with tax
+	Sub, Grand bool
+}
+
+// view validates the document and collects its content. The items table
+// has the columns of the PDF, laid out on the page of the last render.
+func (doc *Document) view() (*documentView, error) {
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	opts, secondary := doc.Options, doc.secondary()
+	primary, other, title, secondaryTitle := doc.recipients()
+	v := &documentView{
+		Title:       doc.label(doc.typeAsString(opts), doc.typeAsString(secondary)),
+		Company:     newContactView(doc, doc.Company),
+		Customer:    newContactView(doc, primary),
+		Description: doc.Description,
+		Notes:       doc.Notes,
+	}
+	if other != nil {
+		cv := newContactView(doc, other)
+		cv.Title = doc.label(title, secondaryTitle)
+		v.OtherParty = &cv
+	}
+
+	v.Metas = append(v.Metas, fmt.Sprintf("%s: %s", doc.label(opts.TextRefTitle, secondary.TextRefTitle), doc.Ref))
+	if len(doc.Version) > 0 {
+		v.Metas = append(v.Metas, fmt.Sprintf("%s: %s", doc.label(opts.TextVersionTitle, secondary.TextVersionTitle), doc.Version))
+	}
+	v.Metas = append(v.Metas, fmt.Sprintf("%s: %s", doc.label(opts.TextDateTitle, secondary.TextDateTitle), doc.date()))
+
+	v.Columns = doc.layoutColumns(doc.tableBounds())
+	for _, item := range doc.Items {
+		row := make([]Cell, len(v.Columns))
+		for i, col := range v.Columns {
+			row[i] = col.Cell(doc, item)
+		}
+		v.Rows = append(v.Rows, row)
+	}
+
+	v.Totals = doc.totalLines()
+
+	if len(doc.PaymentTerm) > 0 {
+		v.PaymentTerm = fmt.Sprintf("%s: %s", doc.label(opts.TextPaymentTermTitle, secondary.TextPaymentTermTitle), doc.PaymentTerm)
+	}
+	if v.Payment = doc.paymentLines(); len(v.Payment) > 0 {
+		v.PaymentTitle = doc.label(opts.TextPaymentTitle, secondary.TextPaymentTitle)
+	}
+
+	if opts.AmountInWords {
+		words, err := doc.TotalInWords()
+		if err != nil {
+			return nil, err
+		}
+		v.InWords = doc.label(opts.TextAmountInWords, secondary.TextAmountInWords) + " " + words
+	}
+
+	return v, nil
+}
+
+// contacts returns the contacts in reading order: the company, the
+// customer block and the other party
+func (v *documentView) contacts() []contactView {
+	contacts := []contactView{v.Company, v.Customer}
+	if v.OtherParty != nil {
+		contacts = append(contacts, *v.OtherParty)
+	}
+	return contacts
+}
+
+func newContactView(doc *Document, c *Contact) contactView {
+	cv := contactView{Name: c.Name, Logo: c.Logo, Details: doc.contactDetails(c), Info: c.AddtionnalInfo}
+	if c.Address != nil {
+		for _, line := range strings.Split(c.Address.ToString(), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				cv.Lines = append(cv.Lines, line)
+			}
+		}
+	}
+	return cv
+}
+
+// totalLines returns the rows of the totals block of the PDF
+func (doc *Document) totalLines() []totalLine {
+	opts, secondary := doc.Options, doc.secondary()
+
+	lines := []totalLine{{
+		Label:  doc.label(opts.TextTotalTotal, secondary.TextTotalTotal),
+		Amount: doc.ac.FormatMoneyDecimal(doc.TotalWithoutTaxAndWithoutDocumentDiscount()),
+	}}
+
+	if doc.Discount != nil {
+		lines = append(lines, totalLine{
+			Label:  doc.label(opts.TextTotalDiscounted, secondary.TextTotalDiscounted),
+			Detail: doc.discountDescription(),
+			Amount: doc.ac.FormatMoneyDecimal(doc.TotalWithoutTax()),
+		})
+	}
+
+	lines = append(lines, totalLine{
+		Label:  doc.label(opts.TextTotalTax, secondary.TextTotalTax),
+		Amount: doc.ac.FormatMoneyDecimal(doc.Tax()),
+	})
+
+	for _, tl := range doc.TaxLines() {
+		label := tl.Name
+		if label == "" {
+			label = doc.label(opts.TextTotalTaxOther, secondary.TextTotalTaxOther)
+			if label == "" {
+				label = "Other"
+			}
+		}
+		lines = append(lines, totalLine{Label: label, Amount: doc.ac.FormatMoneyDecimal(tl.Amount), Sub: true})
+	}
+
+	return append(lines, totalLine{
+		Label:  doc.label(opts.TextTotalWithTax, secondary.TextTotalWithTax),
+		Amount: doc.ac.FormatMoneyDecimal(doc.TotalWithTax()),
+		Grand:  true,
+	})
+}
@@ -0,0 +1,143 @@
+package generator
+
+import "math"
+
+// Watermark is a text or image stamped across the pages of a document, e.g.
+// "DRAFT", "DUPLICATE" or "PAID on 2026-10-01"
+type Watermark struct {
+	// Text is drawn in the bold font, centered on the page. It is shrunk to
+	// fit the page when FontSize is too large.
+	Text     string  `json:"text,omitempty"`
+	FontSize float64 `json:"font_size,omitempty" default:"60"`
+	Color    []int   `json:"color,omitempty" default:"[200,30,30]" validate:"omitempty,len=3"`
+
+	// Border draws a rubber stamp like frame around Text
+	Border bool `json:"border,omitempty"`
+
+	// Image is a PNG, JPEG, GIF or SVG image drawn instead of Text, Width mm
+	// wide
+	Image []byte  `json:"image,omitempty"`
+	Width float64 `json:"width,omitempty" default:"100" validate:"gte=0"`
+
+	// Rotation is the angle in degrees, counter-clockwise
+	Rotation float64 `json:"rotation,omitempty"`
+	Opacity  float64 `json:"opacity,omitempty" default:"0.3" validate:"gte=0,lte=1"`
+
+	// FirstPageOnly stamps the first page only instead of every page
+	FirstPageOnly bool `json:"first_page_only,omitempty"`
+
+	// OverContent draws the watermark over the page content instead of under
+	// it. Watermarks under the content are drawn without transparency when
+	// they can (text on a white page, without letterhead); other watermarks
+	// use an opacity with the Normal blend mode, which PDF/A-2 and PDF/A-3
+	// (see facturx) allow.
+	OverContent bool `json:"over_content,omitempty"`
+}
+
+// validateWatermark reports a watermark with neither text nor image
+func (doc *Document) validateWatermark(verr *ValidationError) {
+	if wm := doc.Watermark; wm != nil && len(wm.Text) == 0 && len(wm.Image) == 0 {
+		verr.add("watermark", "required", "needs a text or an image", nil)
+	}
+}
+
+// appendWatermark stamps the current page with the watermark drawn under
+// (over false) or over the page content
+func (doc *Document) appendWatermark(over bool) {
+	wm := doc.Watermark
+	if wm == nil || wm.OverContent != over || (wm.FirstPageOnly && doc.pdf.PageNo() > 1) {
+		return
+	}
+
+	x, y := doc.pdf.GetXY()
+	pageWidth, pageHeight := doc.pdf.GetPageSize()
+	cx, cy := pageWidth/2, pageHeight/2
+
+	doc.pdf.TransformBegin()
+	doc.pdf.TransformRotate(wm.Rotation, cx, cy)
+	if len(wm.Image) > 0 {
+		doc.appendWatermarkImage(wm, cx, cy)
+	} else {
+		doc.appendWatermarkText(wm, cx, cy, over)
+	}
+	doc.pdf.TransformEnd()
+
+	doc.pdf.SetXY(x, y)
+}
+
+// appendWatermarkImage draws the watermark image centered on cx, cy
+func (doc *Document) appendWatermarkImage(wm *Watermark, cx, cy float64) {
+	svg, ratio := logoImage(wm.Image)
+	if ratio == 0 {
+		return
+	}
+
+	w, h := wm.Width, wm.Width/ratio
+	if wm.Opacity < 1 {
+		doc.pdf.SetAlpha(wm.Opacity, "Normal")
+	}
+	doc.drawImage(wm.Image, svg, cx-w/2, cy-h/2, w, h)
+	if wm.Opacity < 1 {
+		doc.pdf.SetAlpha(1, "Normal")
+	}
+}
+
+// appendWatermarkText draws the watermark text centered on cx, cy
+func (doc *Document) appendWatermarkText(wm *Watermark, cx, cy float64, over bool) {
+	family, style := doc.pdf.GetFontFamily(), doc.pdf.GetFontStyle()
+	fontSize, _ := doc.pdf.GetFontSize()
+	tr, tg, tb := doc.pdf.GetTextColor()
+	dr, dg, db := doc.pdf.GetDrawColor()
+	lineWidth := doc.pdf.GetLineWidth()
+
+	// Shrink the text to 80% of the page along its rotated baseline
+	pageWidth, pageHeight := doc.pdf.GetPageSize()
+	angle := wm.Rotation * math.Pi / 180
+	maxWidth := 0.8 * math.Min(
+		pageWidth/math.Max(math.Abs(math.Cos(angle)), 1e-9),
+		pageHeight/math.Max(math.Abs(math.Sin(angle)), 1e-9),
+	)
+	doc.pdf.SetFont(doc.Options.BoldFont, "B", wm.FontSize)
+	text := doc.encodeString(wm.Text)
+	if width := doc.textWidth(text); width > maxWidth {
+		doc.pdf.SetFontSize(wm.FontSize * maxWidth / width)
+	}
+
+	// On a white page, an opaque lighter color looks the same as the
+	// transparent one. Letterheads are drawn under the content, so the
+	// watermark must let them show through.
+	color := wm.Color
+	transparent := wm.Opacity < 1 && (over || doc.Options.Letterhead != nil)
+	if !over && !transparent {
+		color = make([]int, 3)
+		for i := range color {
+			color[i] = 255 - int(math.Round(float64(255-wm.Color[i])*wm.Opacity))
+		}
+	} else if transparent {
+		doc.pdf.SetAlpha(wm.Opacity, "Normal")
+	}
+	doc.pdf.SetTextColor(color[0], color[1], color[2])
+
+	_, lineHt := doc.pdf.GetFontSize()
+	margin := doc.pdf.GetCellMargin()
+	w, h := doc.textWidth(text)+2*margin, lineHt*1.4
+	border := ""
+	if wm.Border {
+		border = "1"
+		doc.pdf.SetDrawColor(color[0], color[1], color[2])
+		doc.pdf.SetLineWidth(lineHt / 20)
+	}
+	doc.pdf.SetXY(cx-w/2, cy-h/2)
+	doc.drawCell(w, h, text, border, 0, "C", false)
+
+	if transparent {
+		doc.pdf.SetAlpha(1, "Normal")
+	}
+	// No font is set yet under the content of the first page
+	if len(family) > 0 {
+		doc.pdf.SetFont(family, style, fontSize)
+	}
+	doc.pdf.SetTextColor(tr, tg, tb)
+	doc.pdf.SetDrawColor(dr, dg, db)
+	doc.pdf.SetLineWidth(lineWidth)
+}
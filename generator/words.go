@@ -0,0 +1,461 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnsupportedWordsLanguage is returned when amounts cannot be spelled out in the requested language
+var ErrUnsupportedWordsLanguage = errors.New("unsupported amount in words language")
+
+// ErrUnknownCurrencyWords is returned when no currency unit names are known for a currency code
+var ErrUnknownCurrencyWords = errors.New("unknown currency unit names")
+
+// Amount in words languages
+const (
+	WordsLanguageEnglish       string = "en"
+	WordsLanguageEnglishIndian string = "en-IN" // lakh / crore grouping
+	WordsLanguageFrench        string = "fr"
+	WordsLanguageSpanish       string = "es"
+)
+
+// CurrencyWords holds the singular and plural names of a currency unit and
+// of its subunit. Currencies without subunit (e.g. JPY) leave Subunit empty
+// and amounts are rounded to whole units.
+type CurrencyWords struct {
+	Unit          string `json:"unit,omitempty"`
+	UnitPlural    string `json:"unit_plural,omitempty"`
+	Subunit       string `json:"subunit,omitempty"`
+	SubunitPlural string `json:"subunit_plural,omitempty"`
+}
+
+// currencyWords are the built-in currency unit names, by base language and ISO 4217 code
+var currencyWords = map[string]map[string]CurrencyWords{
+	WordsLanguageEnglish: {
+		"EUR": {"euro", "euros", "cent", "cents"},
+		"USD": {"dollar", "dollars", "cent", "cents"},
+		"CAD": {"dollar", "dollars", "cent", "cents"},
+		"AUD": {"dollar", "dollars", "cent", "cents"},
+		"GBP": {"pound", "pounds", "penny", "pence"},
+		"CHF": {"franc", "francs", "centime", "centimes"},
+		"INR": {"rupee", "rupees", "paisa", "paise"},
+		"JPY": {"yen", "yen", "", ""},
+	},
+	WordsLanguageFrench: {
+		"EUR": {"euro", "euros", "centime", "centimes"},
+		"USD": {"dollar", "dollars", "cent", "cents"},
+		"CAD": {"dollar", "dollars", "cent", "cents"},
+		"CHF": {"franc", "francs", "centime", "centimes"},
+		"XOF": {"franc CFA", "francs CFA", "", ""},
+		"XAF": {"franc CFA", "francs CFA", "", ""},
+		"MAD": {"dirham", "dirhams", "centime", "centimes"},
+	},
+	WordsLanguageSpanish: {
+		"EUR": {"euro", "euros", "céntimo", "céntimos"},
+		"USD": {"dólar", "dólares", "centavo", "centavos"},
+		"MXN": {"peso", "pesos", "centavo", "centavos"},
+		"ARS": {"peso", "pesos", "centavo", "centavos"},
+		"COP": {"peso", "pesos", "centavo", "centavos"},
+		"CLP": {"peso", "pesos", "", ""},
+		"PEN": {"sol", "soles", "céntimo", "céntimos"},
+	},
+}
+
+// wordsLanguage describes how amounts are spelled out in one language
+type wordsLanguage struct {
+	base  string
+	spell func(n uint64) string
+	minus string
+	zero  string
+	and   string
+
+	// plural reports whether the currency noun following n takes its plural form
+	plural func(n uint64) bool
+
+	// of returns the preposition inserted between n and the currency noun
+	// ("un million d'euros"), if any
+	of func(n uint64, noun string) string
+}
+
+var wordsLanguages = map[string]wordsLanguage{
+	WordsLanguageEnglish: {
+		base: WordsLanguageEnglish, spell: spellEnglish, minus: "minus", zero: "zero", and: "and",
+		plural: func(n uint64) bool { return n != 1 },
+		of:     func(uint64, string) string { return "" },
+	},
+	WordsLanguageEnglishIndian: {
+		base: WordsLanguageEnglish, spell: spellEnglishIndian, minus: "minus", zero: "zero", and: "and",
+		plural: func(n uint64) bool { return n != 1 },
+		of:     func(uint64, string) string { return "" },
+	},
+	WordsLanguageFrench: {
+		base: WordsLanguageFrench, spell: spellFrench, minus: "moins", zero: "zéro", and: "et",
+		plural: func(n uint64) bool { return n >= 2 },
+		of: func(n uint64, noun string) string {
+			if n == 0 || n%1000000 != 0 {
+				return ""
+			}
+			if strings.ContainsRune("aeiouyhéèêâàîô", []rune(strings.ToLower(noun))[0]) {
+				return "d'"
+			}
+			return "de "
+		},
+	},
+	WordsLanguageSpanish: {
+		base: WordsLanguageSpanish, spell: spellSpanish, minus: "menos", zero: "cero", and: "con",
+		plural: func(n uint64) bool { return n != 1 },
+		of: func(n uint64, _ string) string {
+			if n == 0 || n%1000000 != 0 {
+				return ""
+			}
+			return "de "
+		},
+	},
+}
+
+// LookupCurrencyWords returns the built-in unit names of the ISO 4217
+// currency code in language (e.g. "fr", "en-IN")
+func LookupCurrencyWords(language, currencyCode string) (CurrencyWords, error) {
+	lang, ok := wordsLanguages[language]
+	if !ok {
+		return CurrencyWords{}, ErrUnsupportedWordsLanguage
+	}
+	cw, ok := currencyWords[lang.base][strings.ToUpper(currencyCode)]
+	if !ok {
+		return CurrencyWords{}, ErrUnknownCurrencyWords
+	}
+	return cw, nil
+}
+
+// AmountToWords spells out amount in language followed by the currency unit
+// names, e.g. "mille deux cents euros et cinquante centimes". The amount is
+// rounded to the subunit (hundredths), or to whole units when the currency
+// has no subunit.
+func AmountToWords(amount decimal.Decimal, language string, currency CurrencyWords) (string, error) {
+	lang, ok := wordsLanguages[language]
+	if !ok {
+		return "", ErrUnsupportedWordsLanguage
+	}
+
+	places := int32(2)
+	if len(currency.Subunit) == 0 {
+		places = 0
+	}
+	amount = amount.Round(places)
+
+	var words []string
+	if amount.IsNegative() {
+		words = append(words, lang.minus)
+		amount = amount.Neg()
+	}
+
+	units := uint64(amount.IntPart())
+	subunits := uint64(amount.Sub(decimal.NewFromInt(int64(units))).Shift(places).IntPart())
+
+	if units > 0 || subunits == 0 {
+		words = append(words, lang.spellNoun(units, currency.Unit, currency.UnitPlural))
+	}
+
+	if subunits > 0 {
+		if units > 0 {
+			words = append(words, lang.and)
+		}
+		words = append(words, lang.spellNoun(subunits, currency.Subunit, currency.SubunitPlural))
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// spellNoun spells n followed by the matching form of the noun
+func (l wordsLanguage) spellNoun(n uint64, singular, plural string) string {
+	number := l.zero
+	if n > 0 {
+		number = l.spell(n)
+	}
+
+	noun := singular
+	if l.plural(n) {
+		noun = plural
+	}
+	if len(noun) == 0 {
+		return number
+	}
+
+	return number + " " + l.of(n, noun) + noun
+}
+
+// TotalInWords spells out the total with tax using
+// Options.AmountInWordsLanguage and the unit names of Options.CurrencyWords,
+// or of Options.AmountInWordsCurrency when CurrencyWords is nil.
+// Monetary values must have been prepared by Validate (or Build).
+func (doc *Document) TotalInWords() (string, error) {
+	currency, err := doc.currencyWords()
+	if err != nil {
+		return "", err
+	}
+	return AmountToWords(doc.TotalWithTax(), doc.Options.AmountInWordsLanguage, currency)
+}
+
+// currencyWords returns the unit names used to spell amounts of the document
+func (doc *Document) currencyWords() (CurrencyWords, error) {
+	if doc.Options.CurrencyWords != nil {
+		if _, ok := wordsLanguages[doc.Options.AmountInWordsLanguage]; !ok {
+			return CurrencyWords{}, ErrUnsupportedWordsLanguage
+		}
+		return *doc.Options.CurrencyWords, nil
+	}
+	return LookupCurrencyWords(doc.Options.AmountInWordsLanguage, doc.Options.AmountInWordsCurrency)
+}
+
+// -----------------------------------------------------------------------
+
+var englishUnits = []string{
+	"", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+	"seventeen", "eighteen", "nineteen",
+}
+
+var englishTens = []string{
+	"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety",
+}
+
+var englishScales = []string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+
+// englishBelow1000 spells 1 ≤ n ≤ 999 ("one hundred twenty-three")
+func englishBelow1000(n uint64) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, englishUnits[n/100]+" hundred")
+		n %= 100
+	}
+	switch {
+	case n >= 20 && n%10 != 0:
+		parts = append(parts, englishTens[n/10]+"-"+englishUnits[n%10])
+	case n >= 20:
+		parts = append(parts, englishTens[n/10])
+	case n > 0:
+		parts = append(parts, englishUnits[n])
+	}
+	return strings.Join(parts, " ")
+}
+
+// spellEnglish spells n > 0 using short scale thousands grouping
+func spellEnglish(n uint64) string {
+	var parts []string
+	for scale := 0; n > 0; scale++ {
+		if group := n % 1000; group > 0 {
+			words := englishBelow1000(group)
+			if len(englishScales[scale]) > 0 {
+				words += " " + englishScales[scale]
+			}
+			parts = append([]string{words}, parts...)
+		}
+		n /= 1000
+	}
+	return strings.Join(parts, " ")
+}
+
+// spellEnglishIndian spells n > 0 using the Indian numbering system
+// (thousand, lakh, crore)
+func spellEnglishIndian(n uint64) string {
+	var parts []string
+	if n >= 10000000 {
+		parts = append(parts, spellEnglishIndian(n/10000000)+" crore")
+		n %= 10000000
+	}
+	if lakh := n / 100000; lakh > 0 {
+		parts = append(parts, englishBelow1000(lakh)+" lakh")
+	}
+	if thousand := n / 1000 % 100; thousand > 0 {
+		parts = append(parts, englishBelow1000(thousand)+" thousand")
+	}
+	if rest := n % 1000; rest > 0 {
+		parts = append(parts, englishBelow1000(rest))
+	}
+	return strings.Join(parts, " ")
+}
+
+// -----------------------------------------------------------------------
+
+var frenchUnits = []string{
+	"", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf",
+	"dix", "onze", "douze", "treize", "quatorze", "quinze", "seize",
+	"dix-sept", "dix-huit", "dix-neuf",
+}
+
+var frenchTens = []string{"", "", "vingt", "trente", "quarante", "cinquante", "soixante"}
+
+var frenchScales = []struct {
+	value            uint64
+	singular, plural string
+}{
+	{1000000000000000000, "trillion", "trillions"},
+	{1000000000000000, "billiard", "billiards"},
+	{1000000000000, "billion", "billions"},
+	{1000000000, "milliard", "milliards"},
+	{1000000, "million", "millions"},
+}
+
+// frenchBelow100 spells 1 ≤ n ≤ 99. final tells whether the number ends the
+// numeral, in which case "quatre-vingts" takes its plural s.
+func frenchBelow100(n uint64, final bool) string {
+	tens, unit := n/10, n%10
+	switch {
+	case n < 20:
+		return frenchUnits[n]
+	case tens == 7:
+		if unit == 1 {
+			return "soixante et onze"
+		}
+		return "soixante-" + frenchUnits[10+unit]
+	case tens == 8:
+		if unit == 0 {
+			if final {
+				return "quatre-vingts"
+			}
+			return "quatre-vingt"
+		}
+		return "quatre-vingt-" + frenchUnits[unit]
+	case tens == 9:
+		return "quatre-vingt-" + frenchUnits[10+unit]
+	case unit == 0:
+		return frenchTens[tens]
+	case unit == 1:
+		return frenchTens[tens] + " et un"
+	default:
+		return frenchTens[tens] + "-" + frenchUnits[unit]
+	}
+}
+
+// frenchBelow1000 spells 1 ≤ n ≤ 999. "cent" and "quatre-vingt" only take
+// their plural s when final (not followed by "mille").
+func frenchBelow1000(n uint64, final bool) string {
+	hundreds, rest := n/100, n%100
+
+	var parts []string
+	switch {
+	case hundreds == 1:
+		parts = append(parts, "cent")
+	case hundreds > 1 && rest == 0 && final:
+		parts = append(parts, frenchUnits[hundreds]+" cents")
+	case hundreds > 1:
+		parts = append(parts, frenchUnits[hundreds]+" cent")
+	}
+	if rest > 0 {
+		parts = append(parts, frenchBelow100(rest, final))
+	}
+	return strings.Join(parts, " ")
+}
+
+// spellFrench spells n > 0 using traditional French spelling
+// ("mille deux cents", "quatre-vingts millions")
+func spellFrench(n uint64) string {
+	var parts []string
+	for _, scale := range frenchScales {
+		count := n / scale.value
+		if count == 0 {
+			continue
+		}
+		n %= scale.value
+
+		noun := scale.singular
+		if count > 1 {
+			noun = scale.plural
+		}
+		parts = append(parts, spellFrench(count)+" "+noun)
+	}
+
+	// At this point n < 1 000 000.
+	if thousands := n / 1000; thousands == 1 {
+		parts = append(parts, "mille")
+	} else if thousands > 1 {
+		parts = append(parts, frenchBelow1000(thousands, false)+" mille")
+	}
+	if rest := n % 1000; rest > 0 {
+		parts = append(parts, frenchBelow1000(rest, true))
+	}
+	return strings.Join(parts, " ")
+}
+
+// -----------------------------------------------------------------------
+
+// spanishBelow30 holds the apocopated forms used before a noun ("un", "veintiún")
+var spanishBelow30 = []string{
+	"", "un", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
+	"diez", "once", "doce", "trece", "catorce", "quince", "dieciséis",
+	"diecisiete", "dieciocho", "diecinueve", "veinte", "veintiún", "veintidós",
+	"veintitrés", "veinticuatro", "veinticinco", "veintiséis", "veintisiete",
+	"veintiocho", "veintinueve",
+}
+
+var spanishTens = []string{
+	"", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa",
+}
+
+var spanishHundreds = []string{
+	"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos",
+	"seiscientos", "setecientos", "ochocientos", "novecientos",
+}
+
+// spanishBelow1000 spells 1 ≤ n ≤ 999
+func spanishBelow1000(n uint64) string {
+	hundreds, rest := n/100, n%100
+	if n == 100 {
+		return "cien"
+	}
+
+	var parts []string
+	if hundreds > 0 {
+		parts = append(parts, spanishHundreds[hundreds])
+	}
+	switch {
+	case rest >= 30 && rest%10 != 0:
+		parts = append(parts, spanishTens[rest/10]+" y "+spanishBelow30[rest%10])
+	case rest >= 30:
+		parts = append(parts, spanishTens[rest/10])
+	case rest > 0:
+		parts = append(parts, spanishBelow30[rest])
+	}
+	return strings.Join(parts, " ")
+}
+
+// spanishBelowMillion spells 1 ≤ n ≤ 999 999
+func spanishBelowMillion(n uint64) string {
+	var parts []string
+	if thousands := n / 1000; thousands == 1 {
+		parts = append(parts, "mil")
+	} else if thousands > 1 {
+		parts = append(parts, spanishBelow1000(thousands)+" mil")
+	}
+	if rest := n % 1000; rest > 0 {
+		parts = append(parts, spanishBelow1000(rest))
+	}
+	return strings.Join(parts, " ")
+}
+
+// spellSpanish spells n > 0 before a noun, using the long scale
+// ("un millón", "mil millones", "un billón")
+func spellSpanish(n uint64) string {
+	var parts []string
+	if billions := n / 1000000000000; billions > 0 {
+		noun := " billones"
+		if billions == 1 {
+			noun = " billón"
+		}
+		parts = append(parts, spellSpanish(billions)+noun)
+		n %= 1000000000000
+	}
+	if millions := n / 1000000; millions > 0 {
+		noun := " millones"
+		if millions == 1 {
+			noun = " millón"
+		}
+		parts = append(parts, spanishBelowMillion(millions)+noun)
+	}
+	if rest := n % 1000000; rest > 0 {
+		parts = append(parts, spanishBelowMillion(rest))
+	}
+	return strings.Join(parts, " ")
+}
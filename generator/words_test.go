@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAmountToWords(t *testing.T) {
+	tests := []struct {
+		amount   string
+		language string
+		currency string
+		want     string
+	}{
+		{"1200", WordsLanguageFrench, "EUR", "mille deux cents euros"},
+		{"1", WordsLanguageFrench, "EUR", "un euro"},
+		{"0.5", WordsLanguageFrench, "EUR", "cinquante centimes"},
+		{"71.80", WordsLanguageFrench, "EUR", "soixante et onze euros et quatre-vingts centimes"},
+		{"80000", WordsLanguageFrench, "EUR", "quatre-vingt mille euros"},
+		{"200000000", WordsLanguageFrench, "EUR", "deux cents millions d'euros"},
+		{"1991.01", WordsLanguageFrench, "EUR", "mille neuf cent quatre-vingt-onze euros et un centime"},
+		{"21", WordsLanguageFrench, "XOF", "vingt et un francs CFA"},
+		{"1234.56", WordsLanguageEnglish, "USD", "one thousand two hundred thirty-four dollars and fifty-six cents"},
+		{"1", WordsLanguageEnglish, "GBP", "one pound"},
+		{"0", WordsLanguageEnglish, "EUR", "zero euros"},
+		{"-3.01", WordsLanguageEnglish, "EUR", "minus three euros and one cent"},
+		{"1234567.89", WordsLanguageEnglishIndian, "INR", "twelve lakh thirty-four thousand five hundred sixty-seven rupees and eighty-nine paise"},
+		{"250000000", WordsLanguageEnglishIndian, "INR", "twenty-five crore rupees"},
+		{"1000000000000", WordsLanguageEnglishIndian, "INR", "one lakh crore rupees"},
+		{"1200", WordsLanguageSpanish, "EUR", "mil doscientos euros"},
+		{"21", WordsLanguageSpanish, "MXN", "veintiún pesos"},
+		{"100", WordsLanguageSpanish, "USD", "cien dólares"},
+		{"31.31", WordsLanguageSpanish, "EUR", "treinta y un euros con treinta y un céntimos"},
+		{"21000000", WordsLanguageSpanish, "EUR", "veintiún millones de euros"},
+		{"1001000", WordsLanguageSpanish, "EUR", "un millón mil euros"},
+	}
+
+	for _, tt := range tests {
+		currency, err := LookupCurrencyWords(tt.language, tt.currency)
+		if err != nil {
+			t.Fatalf("LookupCurrencyWords(%s, %s): %v", tt.language, tt.currency, err)
+		}
+
+		got, err := AmountToWords(decimal.RequireFromString(tt.amount), tt.language, currency)
+		if err != nil {
+			t.Fatalf("AmountToWords(%s, %s): %v", tt.amount, tt.language, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("AmountToWords(%s, %s) = %q, want %q", tt.amount, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestAmountToWordsUnsupportedLanguage(t *testing.T) {
+	_, err := AmountToWords(decimal.NewFromInt(1), "xx", CurrencyWords{Unit: "unit"})
+	if !errors.Is(err, ErrUnsupportedWordsLanguage) {
+		t.Fatalf("expected ErrUnsupportedWordsLanguage, got %v", err)
+	}
+
+	if _, err := LookupCurrencyWords(WordsLanguageFrench, "XXX"); !errors.Is(err, ErrUnknownCurrencyWords) {
+		t.Fatalf("expected ErrUnknownCurrencyWords, got %v", err)
+	}
+}
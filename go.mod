@@ -7,8 +7,11 @@ require (
 	github.com/creasty/defaults v1.8.0
 	github.com/go-playground/validator/v10 v10.30.2
 	github.com/leekchan/accounting v0.3.1
+	github.com/mattn/go-runewidth v0.0.23
 	github.com/pdfcpu/pdfcpu v0.12.0
 	github.com/shopspring/decimal v1.4.0
+	golang.org/x/image v0.39.0
+	golang.org/x/text v0.36.0
 )
 
 require (
@@ -21,11 +24,8 @@ require (
 	github.com/hhrutter/pkcs7 v0.2.2 // indirect
 	github.com/hhrutter/tiff v1.0.3 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
-	github.com/mattn/go-runewidth v0.0.23 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	golang.org/x/crypto v0.50.0 // indirect
-	golang.org/x/image v0.39.0 // indirect
 	golang.org/x/sys v0.43.0 // indirect
-	golang.org/x/text v0.36.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )